@@ -1,5 +1,66 @@
 package clevergo
 
+import "fmt"
+
+// The lifecycle methods shared by web and restful controllers.
+type controllerLifecycle interface {
+	Init(action Action, ctx *Context)
+	BeforeAction() error
+	AfterAction(result interface{}, err error) error
+	BeforeResponse()
+	Finally()
+}
+
+// Run the controller's lifecycle and the action:
+// Init -> BeforeAction -> action -> AfterAction -> BeforeResponse -> Finally,
+// BeforeResponse and Finally are always invoked, even if BeforeAction terminates the request or the action panics.
+func runAction(a Action, ctx *Context, invoker *actionInvoker) {
+	controller := a.Controller()
+
+	// Get controller's instance from pool.
+	cv := controller.getInstance()
+	defer controller.putInstance(cv)
+
+	// Inject services into controller's fields.
+	if err := controller.inject(a.App().container, ctx, cv); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// The lifecycle methods are invoked through interface instead of reflection.
+	c := cv.Interface().(controllerLifecycle)
+
+	// Invoke controller's Init() method.
+	c.Init(a, ctx)
+
+	// Invoke controller's Finally() method, even if the action panics.
+	defer c.Finally()
+
+	// Invoke controller's BeforeResponse() method, such as saving the session, even if the action panics.
+	defer c.BeforeResponse()
+
+	// Invoke controller's BeforeAction() method.
+	// The request will be terminated instantly, if BeforeAction() returns an error.
+	if err := c.BeforeAction(); err != nil {
+		if err != ErrAbort {
+			ctx.Error(err)
+		}
+		return
+	}
+
+	// Invoke controller's action and AfterAction() method.
+	// The error of parsing form is reported if the action ignored it, such as reading the form by FormValue.
+	result, err := invoker.call(ctx, cv)
+	if err == nil {
+		err = ctx.Request.FormError()
+	}
+	if err = c.AfterAction(result, err); err != nil {
+		ctx.Error(err)
+	} else if err = invoker.renderResult(ctx, result); err != nil {
+		ctx.Error(err)
+	}
+}
+
 type Action interface {
 	Handle(*Context)
 	Controller() *ControllerInfo
@@ -7,17 +68,42 @@ type Action interface {
 	PrettyName() string
 }
 
-func getActionHandler(a Action) Handler {
-	finalHandler := HandlerFunc(a.Handle)
+// Generate action's handler, the middlewares those can be skipped will be excluded from the chain,
+// and the action's middlewares will be appended to the application's.
+func getActionHandler(a Action, skipMiddlewares SkipMiddlewares, actionMiddlewares []Middleware) Handler {
+	middlewares := make([]Middleware, 0, len(a.App().middlewares)+len(actionMiddlewares))
+	for i := 0; i < len(a.App().middlewares); i++ {
+		if skip, ok := skipMiddlewares[a.App().middlewares[i].ID()]; ok && skip {
+			continue
+		}
+		middlewares = append(middlewares, a.App().middlewares[i])
+	}
+	middlewares = append(middlewares, actionMiddlewares...)
+
+	return chainMiddlewares(middlewares, HandlerFunc(a.Handle))
+}
 
-	middlewareLen := len(a.App().middlewares)
-	if middlewareLen > 0 {
-		handler := a.App().middlewares[middlewareLen-1].Handle(finalHandler)
-		for i := middlewareLen - 2; i >= 0; i-- {
-			handler = a.App().middlewares[i].Handle(handler)
+// Print warning for each middleware which is skipped by the controller but not registered in the application.
+func checkSkipMiddlewares(a Action, skipMiddlewares SkipMiddlewares) {
+	for id := range skipMiddlewares {
+		registered := false
+		for i := 0; i < len(a.App().middlewares); i++ {
+			if a.App().middlewares[i].ID() == id {
+				registered = true
+				break
+			}
+		}
+		if !registered {
+			a.App().logWarn(fmt.Sprintf("Controller \"%s\" skips the middleware \"%s\" which is not registered.", a.Controller().FullName(), id))
 		}
-		return handler
 	}
+}
 
-	return finalHandler
+// Wrap the final handler with the middlewares, the first middleware will be invoked first.
+func chainMiddlewares(middlewares []Middleware, finalHandler Handler) Handler {
+	handler := finalHandler
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].Handle(handler)
+	}
+	return handler
 }
@@ -0,0 +1,287 @@
+package clevergo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Result is a value returned by action which renders itself into the response.
+type Result interface {
+	Render(ctx *Context) error
+}
+
+var (
+	errorType          = reflect.TypeOf((*error)(nil)).Elem()
+	resultType         = reflect.TypeOf((*Result)(nil)).Elem()
+	contextType        = reflect.TypeOf((*Context)(nil))
+	requestType        = reflect.TypeOf((*Request)(nil))
+	responseType       = reflect.TypeOf((*Response)(nil))
+	paramsType         = reflect.TypeOf(Params{})
+	httpRequestType    = reflect.TypeOf((*http.Request)(nil))
+	responseWriterType = reflect.TypeOf((*http.ResponseWriter)(nil)).Elem()
+)
+
+// Resolve an argument of action from the context.
+type argumentResolver func(ctx *Context) (reflect.Value, error)
+
+// Render the value returned by action.
+type resultRenderer func(ctx *Context, v interface{}) error
+
+// actionInvoker invokes the controller's action,
+// the action's arguments and return values are resolved once on registration.
+type actionInvoker struct {
+	index     int                // action's index of controller methods.
+	simple    bool               // whether the action's signature is func().
+	arguments []argumentResolver // action's arguments resolvers.
+	hasValue  bool               // whether the action returns a value.
+	hasError  bool               // whether the action returns an error.
+	render    resultRenderer     // the renderer of the returned value.
+}
+
+// Create an invoker for the method of controller.
+//
+// The action's parameters can be:
+// *Context, *Request, *Response, Params, *http.Request, http.ResponseWriter;
+// basic types(string, bool, integer and float), which are resolved from the route's params by the names in order,
+// the names default to the params of the first route, for example, "id" and "name" of "/users/:id/:name";
+// the registered services of application, the pointer of struct should be a registered service;
+// struct, which is decoded from the request by Context.Bind.
+//
+// The error will be returned if the route's param or the service does not exist.
+//
+// The action can returns nothing, an error, a value, or a value and an error.
+// The returned error will be rendered by the application's error handler.
+// The value will be rendered by itself if it implements Result, otherwise it is rendered by the renderer.
+func newActionInvoker(app *Application, method reflect.Method, routes []string, names []string, render resultRenderer) (*actionInvoker, error) {
+	mt := method.Type
+	invoker := &actionInvoker{
+		index:     method.Index,
+		simple:    mt.NumIn() == 1 && mt.NumOut() == 0,
+		arguments: make([]argumentResolver, 0, mt.NumIn()-1),
+		render:    render,
+	}
+
+	if len(names) == 0 && len(routes) > 0 {
+		names = routeParams(routes[0])
+	}
+
+	// The first parameter is the receiver.
+	paramIndex := 0
+	for i := 1; i < mt.NumIn(); i++ {
+		resolver, err := newArgumentResolver(app, mt.In(i), routes, names, &paramIndex)
+		if err != nil {
+			return nil, fmt.Errorf("The action's parameter is invalid: %s, %s.", method.Name, err.Error())
+		}
+		invoker.arguments = append(invoker.arguments, resolver)
+	}
+
+	switch mt.NumOut() {
+	case 0:
+	case 1:
+		if mt.Out(0) == errorType {
+			invoker.hasError = true
+		} else {
+			invoker.hasValue = true
+		}
+	case 2:
+		if mt.Out(1) != errorType {
+			return nil, fmt.Errorf("The action's return values are invalid: %s, the second one should be error.", method.Name)
+		}
+		invoker.hasValue = true
+		invoker.hasError = true
+	default:
+		return nil, fmt.Errorf("The action's return values are invalid: %s, too many return values.", method.Name)
+	}
+
+	return invoker, nil
+}
+
+func newArgumentResolver(app *Application, t reflect.Type, routes []string, names []string, paramIndex *int) (argumentResolver, error) {
+	switch t {
+	case contextType:
+		return func(ctx *Context) (reflect.Value, error) {
+			return reflect.ValueOf(ctx), nil
+		}, nil
+	case requestType:
+		return func(ctx *Context) (reflect.Value, error) {
+			return reflect.ValueOf(ctx.Request), nil
+		}, nil
+	case responseType:
+		return func(ctx *Context) (reflect.Value, error) {
+			return reflect.ValueOf(ctx.Response), nil
+		}, nil
+	case paramsType:
+		return func(ctx *Context) (reflect.Value, error) {
+			return reflect.ValueOf(ctx.Params), nil
+		}, nil
+	case httpRequestType:
+		return func(ctx *Context) (reflect.Value, error) {
+			return reflect.ValueOf(ctx.Request.Request), nil
+		}, nil
+	case responseWriterType:
+		return func(ctx *Context) (reflect.Value, error) {
+			return reflect.ValueOf(&ctx.Response.writer).Elem(), nil
+		}, nil
+	}
+
+	// Route's param, it takes precedence over the services, so that the basic types are always bound to params.
+	if isBasicKind(t.Kind()) {
+		if *paramIndex >= len(names) {
+			return nil, fmt.Errorf("there is no route's param for the %s parameter #%d", t, *paramIndex+1)
+		}
+		name := names[*paramIndex]
+		*paramIndex++
+		for _, route := range routes {
+			if !hasRouteParam(route, name) {
+				return nil, fmt.Errorf("the param %s is not defined in the route %s", name, route)
+			}
+		}
+		return func(ctx *Context) (reflect.Value, error) {
+			v := reflect.New(t).Elem()
+			if err := setValue(v, ctx.Params.ByName(name)); err != nil {
+				return v, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid param %s: %s.", name, err.Error()))
+			}
+			return v, nil
+		}, nil
+	}
+
+	// Registered service.
+	if app.container.Has(t) {
+		return func(ctx *Context) (reflect.Value, error) {
+			return app.container.resolve(ctx, t)
+		}, nil
+	}
+
+	// Request's data.
+	if t.Kind() == reflect.Struct {
+		if err := CheckValidationRules(t); err != nil {
+			return nil, err
+		}
+		return func(ctx *Context) (reflect.Value, error) {
+			v := reflect.New(t)
+			if err := ctx.Bind(v.Interface()); err != nil {
+				return v.Elem(), bindError(err)
+			}
+			return v.Elem(), nil
+		}, nil
+	}
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		return nil, fmt.Errorf("the service %s was not registered, the request's data should be bound to struct %s", t, t.Elem())
+	}
+
+	return nil, fmt.Errorf("unable to resolve type %s", t)
+}
+
+// Returns the names of the route's params, such as "id" and "filepath" of "/users/:id/files/*filepath".
+func routeParams(route string) []string {
+	names := make([]string, 0)
+	for _, segment := range strings.Split(route, "/") {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// Returns a boolean indicating whether the route defines the param.
+func hasRouteParam(route, name string) bool {
+	for _, param := range routeParams(route) {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert the bind's error to HTTPError, the decoding errors are treated as bad request.
+func bindError(err error) error {
+	switch err.(type) {
+	case *HTTPError, httpErrorer:
+		return err
+	}
+	return WrapError(http.StatusBadRequest, err)
+}
+
+// Invoke the action of controller's instance, and returns the action's return value and error.
+// The returned value is nil if the action does not return a value.
+func (ai *actionInvoker) call(ctx *Context, cv reflect.Value) (interface{}, error) {
+	if ai.simple {
+		cv.Method(ai.index).Interface().(func())()
+		return nil, nil
+	}
+
+	args := make([]reflect.Value, len(ai.arguments))
+	for i := 0; i < len(ai.arguments); i++ {
+		arg, err := ai.arguments[i](ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+	}
+
+	values := cv.Method(ai.index).Call(args)
+
+	var result interface{}
+	if ai.hasValue {
+		result = values[0].Interface()
+	}
+
+	if ai.hasError {
+		if err, _ := values[len(values)-1].Interface().(error); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// Render the action's return value, nothing will be rendered if the action does not return a value.
+// The io.Reader will be responded as body's reader without buffering, see also Response.SetBodyReader.
+func (ai *actionInvoker) renderResult(ctx *Context, v interface{}) error {
+	if !ai.hasValue {
+		return nil
+	}
+	switch value := v.(type) {
+	case Result:
+		return value.Render(ctx)
+	case io.Reader:
+		ctx.Response.SetBodyReader(value)
+		return nil
+	}
+	return ai.render(ctx, v)
+}
+
+// Render the web action's return value.
+// The string will be responded as HTML, the []byte will be responded directly,
+// and other values will be responded as JSON.
+func renderWebResult(ctx *Context, v interface{}) error {
+	switch value := v.(type) {
+	case string:
+		ctx.Response.SetHtmlHeader()
+		ctx.Response.body = value
+		return nil
+	case []byte:
+		ctx.Response.body = string(value)
+		return nil
+	}
+	return renderJsonResult(ctx, v)
+}
+
+// Render the restful action's return value as JSON.
+func renderRestResult(ctx *Context, v interface{}) error {
+	return renderJsonResult(ctx, v)
+}
+
+func renderJsonResult(ctx *Context, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ctx.Response.SetJsonHeader()
+	ctx.Response.body = string(data)
+	return nil
+}
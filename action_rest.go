@@ -20,6 +20,9 @@ type RestMethod struct {
 	Name            string
 	Index           int
 	skipMiddlewares map[string]bool // the middleware those can be skipped.
+	middlewares     []Middleware    // method's additional middlewares.
+	invoker         *actionInvoker  // method's invoker.
+	handler         Handler         // method's handler, the skipped middlewares are excluded.
 }
 
 func (ra *RestAction) Controller() *ControllerInfo {
@@ -54,60 +57,50 @@ func (ra *RestAction) AddMethod(method *RestMethod) error {
 }
 
 func (ra *RestAction) Handle(ctx *Context) {
-	// Create controller's reflect value.
-	cv := reflect.New(ra.controller.t)
-
-	// Invoke controller's Init() method.
-	initMethod := cv.MethodByName("Init")
-	initMethod.Call([]reflect.Value{
-		reflect.ValueOf(ra),
-		reflect.ValueOf(ctx),
-	})
-
-	var values []reflect.Value
-
-	// Invoke controller's BeforeAction() method.
-	beforeActionMethod := cv.MethodByName("BeforeAction")
-	values = beforeActionMethod.Call([]reflect.Value{})
-	// The request will be terminated instantly, if BeforeAction() returns false.
-	if value, ok := values[0].Interface().(bool); !ok || !value {
+	restMethod, err := ra.dispatchMethod(ctx)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	// Invoke controller's action.
-	var methodIndex int
-	// Get request method name.
+	runAction(ra, ctx, restMethod.invoker)
+}
+
+// Returns the method which the request is dispatched to, the simulation method takes precedence over
+// the request's method. The body is not read here, it is left to the middlewares of the dispatched method,
+// such as the body limit and upload middlewares.
+func (ra *RestAction) dispatchMethod(ctx *Context) (*RestMethod, error) {
 	method := ctx.Request.SimulateMethod(Configuration.actionMethod)
-	if len(method) > 0 {
-		method = strings.ToUpper(method)
-		if mv, ok := ra.methods[method]; ok {
-			methodIndex = mv.Index
-		} else {
-			ctx.Response.MethodNotAllowed()
-			return
-		}
-	} else {
-		methodIndex = ra.methods[ctx.Request.Method].Index
+	if len(method) == 0 {
+		method = ctx.Request.Method
 	}
-
-	actionMethod := cv.Method(methodIndex) // MethodByIndex is faster than MethodByName.
-	// actionMethod := cv.MethodByName(a.fullName)
-	actionMethod.Call([]reflect.Value{})
-
-	// Invoke controller's BeforeResponse() method.
-	beforeResponseMethod := cv.MethodByName("BeforeResponse")
-	beforeResponseMethod.Call([]reflect.Value{})
-
-	return
+	if mv, ok := ra.methods[strings.ToUpper(method)]; ok {
+		return mv, nil
+	}
+	return nil, NewHTTPError(http.StatusMethodNotAllowed, "")
 }
 
 func GenerateRestActionHandler(ra *RestAction) httprouter.Handle {
-	handler := getActionHandler(ra)
+	ra.controller.resolveInjections(ra.app.container)
+
+	for _, method := range ra.methods {
+		invoker, err := newActionInvoker(ra.app, reflect.PtrTo(ra.controller.t).Method(method.Index), []string{ra.route}, nil, renderRestResult)
+		if err != nil {
+			panic(err)
+		}
+		method.invoker = invoker
+
+		checkSkipMiddlewares(ra, method.skipMiddlewares)
+		method.handler = getActionHandler(ra, method.skipMiddlewares, method.middlewares)
+	}
 
 	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-		ctx := NewContext(ra.app, rw, r, params)
-		ctx.SkipMiddlewares = ra.methods[ctx.Request.Method].skipMiddlewares
+		ctx := acquireContext(ra.app, rw, r, params)
+		ctx.action = ra
+		ctx.problem = ra.app.problemOptions.Rest
 
+		defer releaseContext(ctx)
+		defer ra.app.finishAction(ra.app.startAction(ra, ctx))
 		defer ctx.Flush()
 
 		if Configuration.enableLog {
@@ -115,7 +108,15 @@ func GenerateRestActionHandler(ra *RestAction) httprouter.Handle {
 			defer ctx.Log.Flush()
 		}
 
-		handler.Handle(ctx)
+		// The middlewares of the dispatched method are applied, such as the simulated DELETE method.
+		method, err := ra.dispatchMethod(ctx)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.SkipMiddlewares = method.skipMiddlewares
+
+		method.handler.Handle(ctx)
 
 		return
 	}
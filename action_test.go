@@ -0,0 +1,386 @@
+package clevergo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type BenchController struct {
+	WebController
+}
+
+func (c *BenchController) Actions() WebActionRoutes {
+	return WebActionRoutes{
+		"Index": NewWebActionRoute([]string{"/"}, []string{"GET"}),
+	}
+}
+
+func (c *BenchController) ActionIndex() {
+	c.Response().SetBody("index")
+}
+
+func newBenchWebAction(b *testing.B) *WebAction {
+	Configuration.enableLog = false
+
+	app := NewApplication()
+	app.RegisterWebController(&BenchController{})
+	if len(app.actions) != 1 {
+		b.Fatalf("expected 1 action, got %d", len(app.actions))
+	}
+	GenerateWebActionHandler(app.actions[0])
+	return app.actions[0]
+}
+
+// Invoke the controller's lifecycle methods by reflection on every request, it is used for comparison.
+func reflectHandle(wa *WebAction, ctx *Context) {
+	cv := reflect.New(wa.controller.t)
+	cv.MethodByName("Init").Call([]reflect.Value{reflect.ValueOf(wa), reflect.ValueOf(ctx)})
+	values := cv.MethodByName("BeforeAction").Call([]reflect.Value{})
+	if err, _ := values[0].Interface().(error); err != nil {
+		return
+	}
+	cv.Method(wa.index).Call([]reflect.Value{})
+	cv.MethodByName("BeforeResponse").Call([]reflect.Value{})
+}
+
+func BenchmarkWebActionHandleReflect(b *testing.B) {
+	wa := newBenchWebAction(b)
+	r := httptest.NewRequest("GET", "/", nil)
+	ctx := NewContext(wa.app, httptest.NewRecorder(), r, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reflectHandle(wa, ctx)
+	}
+}
+
+func BenchmarkWebActionHandle(b *testing.B) {
+	wa := newBenchWebAction(b)
+	r := httptest.NewRequest("GET", "/", nil)
+	ctx := NewContext(wa.app, httptest.NewRecorder(), r, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wa.Handle(ctx)
+	}
+}
+
+func BenchmarkWebActionHandler(b *testing.B) {
+	wa := newBenchWebAction(b)
+	handle := GenerateWebActionHandler(wa)
+	r, _ := http.NewRequest("GET", "/", nil)
+	params := httprouter.Params{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handle(httptest.NewRecorder(), r, params)
+	}
+}
+
+type greeter struct {
+	greeting string
+}
+
+type InjectController struct {
+	WebController
+}
+
+func (c *InjectController) Actions() WebActionRoutes {
+	return WebActionRoutes{
+		"Greet": NewWebActionRoute([]string{"/greet/:name/:times"}, []string{"GET"}),
+	}
+}
+
+func (c *InjectController) ActionGreet(g *greeter, name string, times int) (map[string]interface{}, error) {
+	return map[string]interface{}{"message": g.greeting + " " + name, "times": times}, nil
+}
+
+func TestActionInvoker(t *testing.T) {
+	Configuration.enableLog = false
+
+	app := NewApplication()
+	app.RegisterService(&greeter{greeting: "Hello"})
+	app.RegisterWebController(&InjectController{})
+	handle := GenerateWebActionHandler(app.actions[0])
+
+	tests := []struct {
+		times  string
+		status int
+		body   string
+	}{
+		{"3", http.StatusOK, `{"message":"Hello clevergo","times":3}`},
+		{"three", http.StatusBadRequest, ""},
+	}
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/greet/clevergo/"+test.times, nil)
+		// The params are bound by name regardless of their order.
+		handle(rw, r, httprouter.Params{{Key: "times", Value: test.times}, {Key: "name", Value: "clevergo"}})
+
+		if rw.Code != test.status {
+			t.Errorf("times %q: status = %d, expected %d", test.times, rw.Code, test.status)
+		}
+		if len(test.body) > 0 && rw.Body.String() != test.body {
+			t.Errorf("times %q: body = %s, expected %s", test.times, rw.Body.String(), test.body)
+		}
+	}
+}
+
+type InvalidParamController struct {
+	WebController
+}
+
+func (c *InvalidParamController) Actions() WebActionRoutes {
+	return WebActionRoutes{
+		"Show":   {Routes: []string{"/users/:id", "/profiles/:name"}, Methods: []string{"GET"}, Params: []string{"id"}},
+		"Update": NewWebActionRoute([]string{"/users/:id"}, []string{"POST"}),
+	}
+}
+
+func (c *InvalidParamController) ActionShow(id int) {
+}
+
+func (c *InvalidParamController) ActionUpdate(id int, g *greeter) {
+}
+
+func TestActionInvokerRegistrationErrors(t *testing.T) {
+	Configuration.enableLog = false
+
+	app := NewApplication()
+	app.RegisterWebController(&InvalidParamController{})
+	for _, action := range app.actions {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a registration error", action.fullName)
+				}
+			}()
+			GenerateWebActionHandler(action)
+		}()
+	}
+}
+
+// lifecycleRecorder is injected into the controller and the listener, so that the calls are kept in the test.
+type lifecycleRecorder struct {
+	calls []string
+}
+
+func (r *lifecycleRecorder) record(call string) {
+	r.calls = append(r.calls, call)
+}
+
+type LifecycleController struct {
+	WebController
+	Recorder *lifecycleRecorder `inject:""`
+}
+
+func (c *LifecycleController) Actions() WebActionRoutes {
+	return WebActionRoutes{
+		"Fail":     NewWebActionRoute([]string{"/fail"}, []string{"GET"}),
+		"Redirect": NewWebActionRoute([]string{"/redirect"}, []string{"GET"}),
+	}
+}
+
+func (c *LifecycleController) BeforeAction() error {
+	c.Recorder.record("before")
+	if c.Request().URL.Query().Get("deny") != "" {
+		return NewHTTPError(http.StatusForbidden, "")
+	}
+	return nil
+}
+
+func (c *LifecycleController) ActionFail() error {
+	c.Recorder.record("action")
+	return NewHTTPError(http.StatusConflict, "")
+}
+
+func (c *LifecycleController) ActionRedirect() {
+	c.Recorder.record("action")
+	c.Context.Redirect("/login")
+}
+
+func (c *LifecycleController) AfterAction(result interface{}, err error) error {
+	c.Recorder.record("after")
+	return err
+}
+
+func (c *LifecycleController) BeforeResponse() {
+	c.Recorder.record("response")
+}
+
+func (c *LifecycleController) Finally() {
+	c.Recorder.record("finally")
+}
+
+type recordListener struct {
+	recorder *lifecycleRecorder
+	finished *ActionEvent
+}
+
+func (l *recordListener) ActionStarted(event *ActionEvent) {
+	l.recorder.record("started")
+}
+
+func (l *recordListener) ActionFinished(event *ActionEvent) {
+	l.recorder.record("finished")
+	e := *event
+	l.finished = &e
+}
+
+func TestActionLifecycle(t *testing.T) {
+	Configuration.enableLog = false
+
+	recorder := &lifecycleRecorder{}
+	listener := &recordListener{recorder: recorder}
+	app := NewApplication()
+	app.RegisterService(recorder)
+	app.AddActionListener(listener)
+	app.RegisterWebController(&LifecycleController{})
+	handles := make(map[string]httprouter.Handle)
+	for _, action := range app.actions {
+		handles[action.routes[0]] = GenerateWebActionHandler(action)
+	}
+
+	tests := []struct {
+		url      string
+		calls    string
+		status   int
+		hasError bool
+	}{
+		{"/fail", "started,before,action,after,response,finally,finished", http.StatusConflict, true},
+		{"/fail?deny=1", "started,before,response,finally,finished", http.StatusForbidden, true},
+		{"/redirect", "started,before,action,after,response,finally,finished", http.StatusFound, false},
+	}
+	for _, test := range tests {
+		recorder.calls = nil
+		r, _ := http.NewRequest("GET", test.url, nil)
+		handles[r.URL.Path](httptest.NewRecorder(), r, nil)
+
+		if calls := strings.Join(recorder.calls, ","); calls != test.calls {
+			t.Errorf("%s: lifecycle = %s, expected %s", test.url, calls, test.calls)
+		}
+		if listener.finished.Status != test.status || (listener.finished.Error != nil) != test.hasError {
+			t.Errorf("%s: finished event = %+v, expected status %d", test.url, listener.finished, test.status)
+		}
+	}
+}
+
+type recordMiddleware struct {
+	calls *[]string
+}
+
+func (m recordMiddleware) ID() string {
+	return "RecordMiddleware"
+}
+
+func (m recordMiddleware) Handle(next Handler) Handler {
+	return HandlerFunc(func(ctx *Context) {
+		*m.calls = append(*m.calls, "middleware")
+		next.Handle(ctx)
+	})
+}
+
+type SimulateController struct {
+	RestController
+}
+
+func (c *SimulateController) SkipMiddlewares() map[string]SkipMiddlewares {
+	return map[string]SkipMiddlewares{
+		"DELETE": NewSkipMiddlewares("RecordMiddleware"),
+	}
+}
+
+func (c *SimulateController) Post() string {
+	return "post"
+}
+
+func (c *SimulateController) Delete() string {
+	return "delete"
+}
+
+func TestRestSimulateMethod(t *testing.T) {
+	Configuration.enableLog = false
+
+	var calls []string
+	app := NewApplication()
+	app.AddMiddleware(recordMiddleware{calls: &calls})
+	app.RegisterRestController("/items", &SimulateController{})
+	handle := GenerateRestActionHandler(app.resources[0])
+
+	tests := []struct {
+		url      string
+		override string
+		calls    int
+		resp     string
+	}{
+		{"/items", "", 1, `"post"`},
+		{"/items?_method=DELETE", "", 0, `"delete"`},
+		{"/items", "DELETE", 0, `"delete"`},
+		{"/items?_method=PATCH", "", 0, ""},
+	}
+	for _, test := range tests {
+		calls = nil
+		rw := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", test.url, strings.NewReader("name=foo&_method=PUT"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if test.override != "" {
+			r.Header.Set("X-HTTP-Method-Override", test.override)
+		}
+		handle(rw, r, nil)
+
+		if len(test.resp) == 0 {
+			if rw.Code != http.StatusMethodNotAllowed {
+				t.Errorf("%s: status = %d, expected %d", test.url, rw.Code, http.StatusMethodNotAllowed)
+			}
+			continue
+		}
+		if rw.Body.String() != test.resp || len(calls) != test.calls {
+			t.Errorf("%s %q: response = %s with %d middleware calls, expected %s with %d", test.url, test.override, rw.Body.String(), len(calls), test.resp, test.calls)
+		}
+	}
+}
+
+type UnregisteredSkipController struct {
+	RestController
+}
+
+func (c *UnregisteredSkipController) SkipMiddlewares() map[string]SkipMiddlewares {
+	return map[string]SkipMiddlewares{
+		"GET": NewSkipMiddlewares("UnknownMiddleware"),
+	}
+}
+
+func (c *UnregisteredSkipController) Get() string {
+	return "get"
+}
+
+// The warning of skipping unregistered middleware should be printed although the logger is not set.
+func TestCheckSkipMiddlewaresWarning(t *testing.T) {
+	app := NewApplication()
+	app.RegisterRestController("/items", &UnregisteredSkipController{})
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	GenerateRestActionHandler(app.resources[0])
+	os.Stdout = stdout
+	writer.Close()
+
+	output, _ := ioutil.ReadAll(reader)
+	if !strings.Contains(string(output), `Warning: Controller "`) || !strings.Contains(string(output), `"UnknownMiddleware" which is not registered`) {
+		t.Errorf("output = %q, expected the warning of UnknownMiddleware", output)
+	}
+}
@@ -18,6 +18,9 @@ type WebAction struct {
 	controller      *ControllerInfo   // action's controller.
 	handler         httprouter.Handle // action's handle.
 	skipMiddlewares SkipMiddlewares   // the middleware those can be skipped.
+	middlewares     []Middleware      // action's additional middlewares.
+	params          []string          // the names of route's params which the action's basic parameters are bound to.
+	invoker         *actionInvoker    // action's invoker.
 }
 
 func NewWebAction(app *Application, routes []string, methods []string, name string, index int) (*WebAction, error) {
@@ -34,6 +37,7 @@ func NewWebAction(app *Application, routes []string, methods []string, name stri
 		controller:      nil,
 		handler:         nil,
 		skipMiddlewares: make(SkipMiddlewares, 0),
+		middlewares:     make([]Middleware, 0),
 	}
 
 	ai.name = getActionName(name)
@@ -55,45 +59,28 @@ func (wa *WebAction) PrettyName() string {
 }
 
 func (wa *WebAction) Handle(ctx *Context) {
-	// Create controller's reflect value.
-	cv := reflect.New(wa.controller.t)
-
-	// Invoke controller's Init() method.
-	initMethod := cv.MethodByName("Init")
-	initMethod.Call([]reflect.Value{
-		reflect.ValueOf(wa),
-		reflect.ValueOf(ctx),
-	})
-
-	var values []reflect.Value
-
-	// Invoke controller's BeforeAction() method.
-	beforeActionMethod := cv.MethodByName("BeforeAction")
-	values = beforeActionMethod.Call([]reflect.Value{})
-	// The request will be terminated instantly, if BeforeAction() returns false.
-	if value, ok := values[0].Interface().(bool); !ok || !value {
-		return
-	}
-
-	// Invoke controller's action.
-	actionMethod := cv.Method(wa.index) // MethodByIndex is faster than MethodByName.
-	// actionMethod := cv.MethodByName(a.fullName)
-	actionMethod.Call([]reflect.Value{})
-
-	// Invoke controller's BeforeResponse() method.
-	beforeResponseMethod := cv.MethodByName("BeforeResponse")
-	beforeResponseMethod.Call([]reflect.Value{})
-
-	return
+	runAction(wa, ctx, wa.invoker)
 }
 
 func GenerateWebActionHandler(wa *WebAction) httprouter.Handle {
-	handler := getActionHandler(wa)
+	wa.controller.resolveInjections(wa.app.container)
+
+	invoker, err := newActionInvoker(wa.app, reflect.PtrTo(wa.controller.t).Method(wa.index), wa.routes, wa.params, renderWebResult)
+	if err != nil {
+		panic(err)
+	}
+	wa.invoker = invoker
+
+	checkSkipMiddlewares(wa, wa.skipMiddlewares)
+	handler := getActionHandler(wa, wa.skipMiddlewares, wa.middlewares)
 
 	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-		ctx := NewContext(wa.app, rw, r, params)
+		ctx := acquireContext(wa.app, rw, r, params)
+		ctx.action = wa
 		ctx.SkipMiddlewares = wa.skipMiddlewares
 
+		defer releaseContext(ctx)
+		defer wa.app.finishAction(wa.app.startAction(wa, ctx))
 		defer ctx.Flush()
 
 		if Configuration.enableLog {
@@ -110,6 +97,7 @@ func GenerateWebActionHandler(wa *WebAction) httprouter.Handle {
 type WebActionRoute struct {
 	Routes  []string
 	Methods []string
+	Params  []string // the names of route's params which the action's basic parameters are bound to in order, optional.
 }
 
 func NewWebActionRoute(routes []string, args ...[]string) WebActionRoute {
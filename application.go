@@ -1,12 +1,14 @@
 package clevergo
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/clevergo/cache"
 	"github.com/clevergo/jwt"
 	"github.com/clevergo/log"
 	"github.com/clevergo/session"
 	"github.com/julienschmidt/httprouter"
+	"net"
 	"net/http"
 	"path"
 	"reflect"
@@ -14,30 +16,61 @@ import (
 )
 
 type Application struct {
-	router       *httprouter.Router
-	handlers     []*RouteHandler
-	middlewares  []Middleware
-	actions      []*WebAction
-	resources    []*RestAction
-	sessionStore session.Store
-	logger       *log.Logger
-	cache        *cache.RedisCache
-	jwt          *jwt.JWT
-	panicHandler func(http.ResponseWriter, *http.Request, interface{})
+	router          *httprouter.Router
+	handlers        []*RouteHandler
+	preMiddlewares  []Middleware // the middlewares which run before routing.
+	preHandler      Handler      // the handler which runs the pre-router middlewares.
+	middlewares     []Middleware
+	actions         []*WebAction
+	resources       []*RestAction
+	webSockets      []*webSocketRoute
+	sessionStore    session.Store
+	logger          *log.Logger
+	cache           *cache.RedisCache
+	jwt             *jwt.JWT
+	panicHandler    func(http.ResponseWriter, *http.Request, interface{})
+	errorHandler    ErrorHandler
+	problemHandler  ErrorHandler
+	problemOptions  ProblemOptions
+	errorOptions    ErrorOptions
+	uploadOptions   UploadOptions
+	sendfileOptions SendfileOptions
+	maxBodySize     int64            // the max size of request's body, zero means unlimited, negative means the configuration's.
+	trustedProxies  TrustedProxies   // nil means the configuration's.
+	container       *Container       // the services which can be injected into controllers and actions.
+	listeners       []ActionListener // the listeners of actions's lifecycle.
 }
 
 func NewApplication() *Application {
-	return &Application{
-		router:       NewRouter(),
-		handlers:     make([]*RouteHandler, 0),
-		middlewares:  make([]Middleware, 0),
-		actions:      make([]*WebAction, 0),
-		resources:    make([]*RestAction, 0),
-		sessionStore: nil,
-		logger:       nil,
-		cache:        nil,
-		panicHandler: PanicHandler,
+	app := &Application{
+		router:          NewRouter(),
+		handlers:        make([]*RouteHandler, 0),
+		preMiddlewares:  make([]Middleware, 0),
+		preHandler:      nil,
+		middlewares:     make([]Middleware, 0),
+		actions:         make([]*WebAction, 0),
+		resources:       make([]*RestAction, 0),
+		webSockets:      make([]*webSocketRoute, 0),
+		sessionStore:    nil,
+		logger:          nil,
+		cache:           nil,
+		panicHandler:    nil,
+		errorHandler:    DefaultErrorHandler,
+		problemHandler:  DefaultProblemHandler,
+		problemOptions:  NewProblemOptions(),
+		errorOptions:    NewErrorOptions(),
+		uploadOptions:   NewUploadOptions(),
+		sendfileOptions: NewSendfileOptions(),
+		maxBodySize:     -1,
+		trustedProxies:  nil,
+		container:       NewContainer(),
+		listeners:       make([]ActionListener, 0),
 	}
+
+	app.router.NotFound = &NotFoundHandler{app: app}
+	app.router.MethodNotAllowed = &MethodNotAllowedHandler{app: app}
+
+	return app
 }
 
 func (a *Application) AddHandler(path string, methods []string, handler http.Handler) {
@@ -48,10 +81,16 @@ func (a *Application) AddHandler(path string, methods []string, handler http.Han
 	})
 }
 
+// Set the panic handler, which takes over the panics from the error handler.
 func (a *Application) SetPanicHandler(handler func(http.ResponseWriter, *http.Request, interface{})) {
 	a.panicHandler = handler
 }
 
+// Set the error handler, it renders the errors of actions, panics, not found and method not allowed.
+func (a *Application) SetErrorHandler(handler ErrorHandler) {
+	a.errorHandler = handler
+}
+
 func (a *Application) SetMethodNotAllowedHandler(handler http.Handler) {
 	a.router.MethodNotAllowed = handler
 }
@@ -60,6 +99,61 @@ func (a *Application) SetNotFoundHandler(handler http.Handler) {
 	a.router.NotFound = handler
 }
 
+// Set the problem handler, it renders the errors as problem details, see also SetProblemOptions.
+func (a *Application) SetProblemHandler(handler ErrorHandler) {
+	a.problemHandler = handler
+}
+
+// Set the options which specify the errors those are rendered by the problem handler.
+func (a *Application) SetProblemOptions(options ProblemOptions) {
+	a.problemOptions = options
+}
+
+// Set the options of error pages which are rendered by the default error handler.
+func (a *Application) SetErrorOptions(options ErrorOptions) {
+	a.errorOptions = options
+}
+
+// Set the default upload options, it can be overridden per route by Request.SetUploadOptions,
+// such as the upload middleware.
+func (a *Application) SetUploadOptions(options UploadOptions) {
+	a.uploadOptions = options
+}
+
+// Set the options of offloading files to the front server, see also Response.File.
+func (a *Application) SetSendfileOptions(options SendfileOptions) {
+	a.sendfileOptions = options
+}
+
+// Set the max size of request's body, zero means unlimited, negative means the configuration's server.max_body_size.
+// It can be overridden per route by Context.SetMaxBodySize, such as the body limit middleware.
+func (a *Application) SetMaxBodySize(n int64) {
+	a.maxBodySize = n
+}
+
+// Returns the max size of request's body, the configuration is read on every call,
+// so that the applications which are created before loading the configuration respect it.
+func (a *Application) MaxBodySize() int64 {
+	if a.maxBodySize < 0 {
+		return Configuration.serverMaxBodySize
+	}
+	return a.maxBodySize
+}
+
+// Set the trusted proxies, nil means the configuration's server.trusted_proxies,
+// see also Request.ClientIP, Request.Scheme and Request.ForwardedHost.
+func (a *Application) SetTrustedProxies(proxies TrustedProxies) {
+	a.trustedProxies = proxies
+}
+
+// Returns the trusted proxies, the configuration's are returned if they are not set.
+func (a *Application) TrustedProxies() TrustedProxies {
+	if a.trustedProxies == nil {
+		return Configuration.serverTrustedProxies
+	}
+	return a.trustedProxies
+}
+
 func (a *Application) SetSessionStore(store session.Store) {
 	a.sessionStore = store
 }
@@ -76,6 +170,38 @@ func (a *Application) AddMiddleware(middleware Middleware) {
 	a.middlewares = append(a.middlewares, middleware)
 }
 
+// Set the pre-router middlewares.
+// Unlike the action's middlewares, the pre-router middlewares run for every request before routing,
+// including not found, method not allowed, static files, OPTIONS and the handlers added by AddHandler.
+func (a *Application) SetPreMiddlewares(middlewares []Middleware) {
+	a.preMiddlewares = middlewares
+}
+
+// Add a pre-router middleware, see also SetPreMiddlewares.
+func (a *Application) AddPreMiddleware(middleware Middleware) {
+	a.preMiddlewares = append(a.preMiddlewares, middleware)
+}
+
+// Add a listener of actions's lifecycle, see also ActionListener.
+func (a *Application) AddActionListener(listener ActionListener) {
+	a.listeners = append(a.listeners, listener)
+}
+
+// Returns the container of services.
+func (a *Application) Container() *Container {
+	return a.container
+}
+
+// Register a singleton service, see also Container.Singleton.
+func (a *Application) RegisterService(service interface{}) {
+	a.container.Singleton(service)
+}
+
+// Register a singleton service as an interface, see also Container.SingletonAs.
+func (a *Application) RegisterServiceAs(iface interface{}, service interface{}) {
+	a.container.SingletonAs(iface, service)
+}
+
 func (a *Application) RegisterWebControllers(controllers ...WebControllerInterface) {
 	for i := 0; i < len(controllers); i++ {
 		a.RegisterWebController(controllers[i])
@@ -93,6 +219,7 @@ func (a *Application) RegisterWebController(c WebControllerInterface) {
 		pkgPath:  path.Join(Configuration.srcPath, ct.Elem().PkgPath()),
 		layout:   "",
 	}
+	ci.initPool()
 
 	ci.name = getControllerName(ct.Elem().Name())
 	ci.prettyName = PrettyName(ci.name)
@@ -141,6 +268,9 @@ func (a *Application) RegisterWebController(c WebControllerInterface) {
 		}
 	}
 
+	// Get action's middlewares, it is declared by both of the controller's interfaces.
+	actionMiddlewares := c.Middlewares()
+
 	for i := 0; i < ct.NumMethod(); i++ {
 		method := ct.Method(i)
 		if v, ok := actionsRoute[method.Name]; ok {
@@ -155,6 +285,13 @@ func (a *Application) RegisterWebController(c WebControllerInterface) {
 				action.skipMiddlewares = middlewares
 			}
 
+			// Set action's middlewares.
+			if middlewares, ok := actionMiddlewares[method.Name]; ok {
+				action.middlewares = middlewares
+			}
+
+			action.params = v.Params
+
 			action.controller = ci
 			a.actions = append(a.actions, action)
 		}
@@ -171,6 +308,7 @@ func (a *Application) RegisterRestController(route string, c RestControllerInter
 		t:        cv.Elem().Type(),
 		pkgPath:  path.Join(Configuration.srcPath, ct.Elem().PkgPath()),
 	}
+	ci.initPool()
 
 	ci.name = getControllerName(ct.Elem().Name())
 	ci.prettyName = PrettyName(ci.name)
@@ -191,6 +329,9 @@ func (a *Application) RegisterRestController(route string, c RestControllerInter
 		}
 	}
 
+	// Get action's middlewares, it is declared by both of the controller's interfaces.
+	actionMiddlewares := c.Middlewares()
+
 	for i := 0; i < ct.NumMethod(); i++ {
 		method := ct.Method(i)
 
@@ -204,6 +345,7 @@ func (a *Application) RegisterRestController(route string, c RestControllerInter
 				Name:            method.Name,
 				Index:           i,
 				skipMiddlewares: middlewares,
+				middlewares:     actionMiddlewares[strings.ToUpper(method.Name)],
 			})
 			if err != nil {
 				panic(err)
@@ -245,6 +387,12 @@ func (a *Application) Run() {
 		}
 	}
 
+	// Register WebSocket's routes.
+	for i := 0; i < len(a.webSockets); i++ {
+		fmt.Printf("Register WebSocket's route \"%s\" with method: GET\n", a.webSockets[i].path)
+		a.router.Handle("GET", a.webSockets[i].path, a.webSocketHandle(a.webSockets[i]))
+	}
+
 	// Register other handlers.
 	for i := 0; i < len(a.handlers); i++ {
 		for j := 0; j < len(a.handlers[i].Methods); j++ {
@@ -253,6 +401,61 @@ func (a *Application) Run() {
 			a.router.Handler(method, a.handlers[i].Path, a.handlers[i].Handler)
 		}
 	}
+
+	// Generate pre-router handler, the global pre-router middlewares run before the application's.
+	middlewares := make([]Middleware, 0, len(preMiddlewares)+len(a.preMiddlewares))
+	middlewares = append(middlewares, preMiddlewares...)
+	middlewares = append(middlewares, a.preMiddlewares...)
+	if len(middlewares) > 0 {
+		a.preHandler = chainMiddlewares(middlewares, HandlerFunc(a.dispatch))
+	}
+}
+
+// Log the warning by the application's logger, it is printed like the registration messages if the logger is not set.
+func (a *Application) logWarn(message string) {
+	if a.logger == nil {
+		fmt.Printf("Warning: %s\n", message)
+		return
+	}
+	l := a.logger.NewLog()
+	l.Warn(message)
+	l.Flush()
+}
+
+// Dispatch the request to the router, the router's handlers write the response by themselves,
+// and the status they sent is recorded as the response's status, so that the pre-router middlewares can see it.
+func (a *Application) dispatch(ctx *Context) {
+	ctx.Response.SetCancel(true)
+	sw := &statusWriter{ResponseWriter: ctx.Response.writer}
+	a.router.ServeHTTP(sw, ctx.Request.Request)
+	if sw.status != 0 {
+		ctx.Response.status = sw.status
+	}
+}
+
+func (a *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Limit the size of request's body, the limit is checked while reading the body,
+	// so that the routes can override it before then.
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = newBodyLimiter(w, r, a.MaxBodySize())
+	}
+
+	if a.preHandler == nil {
+		a.router.ServeHTTP(w, r)
+		return
+	}
+
+	ctx := acquireContext(a, w, r, nil)
+
+	defer releaseContext(ctx)
+	defer ctx.Flush()
+
+	if Configuration.enableLog {
+		ctx.Log = a.logger.NewLog()
+		defer ctx.Log.Flush()
+	}
+
+	a.preHandler.Handle(ctx)
 }
 
 type Applications map[string]*Application
@@ -261,8 +464,50 @@ func (as Applications) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Get domain from host.
 	host := strings.Split(r.Host, ":")
 	if app, ok := as[host[0]]; ok {
-		app.router.ServeHTTP(w, r)
+		app.ServeHTTP(w, r)
 	} else {
-		defaultApp.router.ServeHTTP(w, r)
+		defaultApp.ServeHTTP(w, r)
+	}
+}
+
+// statusWriter records the status which is sent by the router's handlers.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack the connection, such as WebSocket's handshake, the status is recorded as switching protocols.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
 	}
+	return conn, rw, err
 }
@@ -0,0 +1,76 @@
+package clevergo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// statusRecorder is a pre-router middleware which records the status of each request.
+type statusRecorder struct {
+	statuses map[string]int
+}
+
+func (m *statusRecorder) ID() string {
+	return "StatusRecorder"
+}
+
+func (m *statusRecorder) Handle(next Handler) Handler {
+	return HandlerFunc(func(ctx *Context) {
+		// The path of static files is rewritten by the router, so that the key is taken before dispatching.
+		key := ctx.Request.Method + " " + ctx.Request.URL.Path
+		ctx.Response.Header().Set("X-Pre", "1")
+		next.Handle(ctx)
+		m.statuses[key] = ctx.Response.Status()
+	})
+}
+
+func TestPreMiddlewares(t *testing.T) {
+	Configuration.enableLog = false
+
+	dir, err := ioutil.TempDir("", "clevergo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("var app;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &statusRecorder{statuses: make(map[string]int)}
+	app := NewApplication()
+	app.AddPreMiddleware(recorder)
+	app.AddHandler("/handler", []string{"GET"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	app.RegisterStaticResources("static", dir)
+	app.Run()
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/missing", http.StatusNotFound},
+		{"POST", "/handler", http.StatusMethodNotAllowed},
+		{"GET", "/handler", http.StatusAccepted},
+		{"GET", "/static/app.js", http.StatusOK},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.status {
+			t.Errorf("%s %s: status = %d, expected %d", test.method, test.path, w.Code, test.status)
+		}
+		if w.Header().Get("X-Pre") != "1" {
+			t.Errorf("%s %s: the pre-router middleware does not run", test.method, test.path)
+		}
+		if status := recorder.statuses[test.method+" "+test.path]; status != test.status {
+			t.Errorf("%s %s: recorded status = %d, expected %d", test.method, test.path, status, test.status)
+		}
+	}
+}
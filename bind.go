@@ -0,0 +1,218 @@
+package clevergo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decode the request's data into v and validate it, v should be a pointer of struct.
+//
+// The data is decoded in order:
+// the body is decoded according to the request's Content-Type, JSON and XML bodies are decoded by
+// encoding/json and encoding/xml, otherwise the form values(including the URL query, see also http.Request.Form)
+// are decoded into the fields by the "form" tag;
+// then the URL query values are decoded into the fields by the "query" tag;
+// and the route's params are decoded into the fields by the "param" tag.
+// The fields without any of these tags are decoded from the form values by the field's name.
+//
+// After decoding, v is validated by Validate, and ValidationErrors will be returned if it is invalid,
+// the malformed values, such as "abc" of int field, are returned as ValidationErrors as well.
+func (ctx *Context) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("The bind's destination should be a non-nil pointer.")
+	}
+
+	contentType, _, _ := mime.ParseMediaType(ctx.Request.Header.Get("Content-Type"))
+	switch {
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		if ctx.Request.Body != nil {
+			if err := json.NewDecoder(ctx.Request.Body).Decode(v); err != nil && err != io.EOF {
+				return bodyError(err)
+			}
+		}
+	case contentType == "application/xml" || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml"):
+		if ctx.Request.Body != nil {
+			if err := xml.NewDecoder(ctx.Request.Body).Decode(v); err != nil && err != io.EOF {
+				return bodyError(err)
+			}
+		}
+	default:
+		if err := ctx.Request.parseForm(); err != nil {
+			return err
+		}
+		if rv.Elem().Kind() == reflect.Struct {
+			if err := bindValues(rv.Elem(), "form", ctx.Request.Form, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	if rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	if err := bindValues(rv.Elem(), "query", ctx.Request.URL.Query(), false); err != nil {
+		return err
+	}
+
+	params := make(map[string][]string, len(ctx.Params.Params))
+	for i := 0; i < len(ctx.Params.Params); i++ {
+		params[ctx.Params.Params[i].Key] = []string{ctx.Params.Params[i].Value}
+	}
+	if err := bindValues(rv.Elem(), "param", params, false); err != nil {
+		return err
+	}
+
+	return Validate(v)
+}
+
+// The tags which specify the source of field.
+var bindTags = []string{"form", "query", "param"}
+
+// Decode the values into the struct's fields by the tag, the field will be skipped if its tag is "-".
+// If byName is true, the fields without any bind tags are decoded by the field's name.
+// The malformed values are returned as ValidationErrors with the rule "type", so that they can be
+// re-displayed in forms, but the field of unsupported type is a programmer's error.
+func bindValues(sv reflect.Value, tag string, values map[string][]string, byName bool) error {
+	var errs ValidationErrors
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		// Skip unexported field.
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		name := field.Tag.Get(tag)
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			if !byName || hasBindTag(field) {
+				continue
+			}
+			name = field.Name
+		}
+
+		vs, ok := values[name]
+		if !ok || len(vs) == 0 {
+			continue
+		}
+
+		fv := sv.Field(i)
+		var err error
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			slice := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+			for j := 0; j < len(vs) && err == nil; j++ {
+				err = setValue(slice.Index(j), vs[j])
+			}
+			if err == nil {
+				fv.Set(slice)
+			}
+		} else {
+			err = setValue(fv, vs[0])
+		}
+
+		if err != nil {
+			if _, ok := err.(*unsupportedTypeError); ok {
+				return fmt.Errorf("Invalid field %s: %s.", name, err.Error())
+			}
+			errs = append(errs, &FieldError{
+				Field:   name,
+				Rule:    "type",
+				Param:   fv.Type().String(),
+				Message: fmt.Sprintf("%s is malformed.", name),
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func hasBindTag(field reflect.StructField) bool {
+	for i := 0; i < len(bindTags); i++ {
+		if _, ok := field.Tag.Lookup(bindTags[i]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a boolean indicating whether the type can be parsed from a string by setValue.
+func isBasicKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// unsupportedTypeError is returned by setValue if the value's type can not be parsed from a string.
+type unsupportedTypeError struct {
+	t reflect.Type
+}
+
+func (e *unsupportedTypeError) Error() string {
+	return "unsupported type " + e.t.String()
+}
+
+// Parse the string s and set it into v, the booleans are parsed by ParseBool.
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), s)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		// []byte
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+		return &unsupportedTypeError{v.Type()}
+	default:
+		return &unsupportedTypeError{v.Type()}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package clevergo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type bindForm struct {
+	ID    int      `param:"id"`
+	Page  int      `query:"page" validate:"min=1"`
+	Name  string   `json:"name" form:"name" validate:"required,min=3"`
+	Email string   `json:"email" form:"email" validate:"email"`
+	Tags  []string `json:"tags" form:"tags"`
+	Role  string   `json:"role" validate:"oneof=admin user"`
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		fields      map[string]string // the fields those should be invalid.
+	}{
+		{"application/json", `{"name":"clevergo","email":"a@b.com","tags":["a","b"],"role":"user"}`, nil},
+		{"application/x-www-form-urlencoded", "name=clevergo&email=a%40b.com&tags=a&tags=b", nil},
+		{"application/json", `{"name":"cg","email":"invalid","role":"guest"}`, map[string]string{"name": "min", "email": "email", "role": "oneof"}},
+		{"application/x-www-form-urlencoded", "email=", map[string]string{"name": "required"}},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/users/7?page=2", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+		ctx := acquireContext(NewApplication(), httptest.NewRecorder(), r, httprouter.Params{{Key: "id", Value: "7"}})
+
+		var form bindForm
+		err := ctx.Bind(&form)
+		releaseContext(ctx)
+
+		if test.fields == nil {
+			if err != nil {
+				t.Errorf("Bind(%s) returns error: %s", test.body, err)
+				continue
+			}
+			if form.ID != 7 || form.Page != 2 || form.Name != "clevergo" || len(form.Tags) != 2 {
+				t.Errorf("Bind(%s) = %+v", test.body, form)
+			}
+			continue
+		}
+
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			t.Errorf("Bind(%s) returns %v, expected ValidationErrors", test.body, err)
+			continue
+		}
+		if len(errs) != len(test.fields) {
+			t.Errorf("Bind(%s) returns %d errors, expected %d: %s", test.body, len(errs), len(test.fields), errs)
+		}
+		for _, e := range errs {
+			if rule, ok := test.fields[e.Field]; !ok || rule != e.Rule {
+				t.Errorf("Bind(%s) returns unexpected error: %s(%s)", test.body, e.Field, e.Rule)
+			}
+		}
+		if status := ToHTTPError(err).Status; status != http.StatusUnprocessableEntity {
+			t.Errorf("status of validation errors = %d, expected %d", status, http.StatusUnprocessableEntity)
+		}
+	}
+}
+
+type ageForm struct {
+	Age  int      `json:"age" validate:"min=18"`
+	Next *ageForm `json:"next"`
+	Tags []string `json:"tags" validate:"min=1"`
+}
+
+type unknownRuleForm struct {
+	Name string `validate:"required,slug"`
+}
+
+func TestValidate(t *testing.T) {
+	form := &ageForm{Age: 0}
+	// The cycle should be detected.
+	form.Next = form
+	errs, ok := Validate(form).(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Field != "age" || errs[0].Rule != "min" {
+		t.Errorf("Validate() = %v, expected that zero age is invalid only", errs)
+	}
+
+	err := Validate(&unknownRuleForm{Name: "clevergo"})
+	if err == nil || ToHTTPError(bindError(err)).Status != http.StatusInternalServerError {
+		t.Errorf("Validate() with unknown rule returns %v, expected internal server error", err)
+	}
+	if CheckValidationRules(reflect.TypeOf(unknownRuleForm{})) == nil {
+		t.Error("the unknown rule should be reported")
+	}
+	if err := CheckValidationRules(reflect.TypeOf(ageForm{})); err != nil {
+		t.Errorf("CheckValidationRules() returns error: %s", err)
+	}
+}
+
+type profileForm struct {
+	Agree bool `form:"agree"`
+	Age   int  `form:"age"`
+}
+
+type unsupportedForm struct {
+	Meta map[string]string `form:"meta"`
+}
+
+func TestBindMalformedValues(t *testing.T) {
+	bind := func(body string, v interface{}) error {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := acquireContext(NewApplication(), httptest.NewRecorder(), r, nil)
+		defer releaseContext(ctx)
+		return ctx.Bind(v)
+	}
+
+	// The value of HTML checkbox.
+	var form profileForm
+	if err := bind("agree=on&age=18", &form); err != nil || !form.Agree || form.Age != 18 {
+		t.Errorf("Bind() = %+v, %v", form, err)
+	}
+
+	err := bind("agree=maybe&age=abc", &profileForm{})
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 2 || errs.Map()["agree"] == "" || errs.Map()["age"] == "" || errs[0].Rule != "type" {
+		t.Errorf("Bind() with malformed values returns %v, expected the errors of agree and age", err)
+	}
+	if status := ToHTTPError(err).Status; status != http.StatusUnprocessableEntity {
+		t.Errorf("status of malformed values = %d, expected %d", status, http.StatusUnprocessableEntity)
+	}
+
+	err = bind("meta=foo", &unsupportedForm{})
+	if _, ok := err.(ValidationErrors); ok || err == nil || ToHTTPError(err).Status != http.StatusInternalServerError {
+		t.Errorf("Bind() with unsupported type returns %v, expected internal server error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package clevergo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+var errBodyConsumed = errors.New("the request's body has been read, its size limit can not be changed")
+
+// bodyLimiter limits the size of request's body by http.MaxBytesReader, so that the server closes
+// the connection if the body exceeds the limit. The limit can be changed before the body is consumed,
+// so that the routes can override the application's limit.
+type bodyLimiter struct {
+	w             http.ResponseWriter // the server's writer, which is told to close the connection.
+	body          io.ReadCloser
+	contentLength int64
+	limit         int64         // zero means unlimited.
+	reader        io.ReadCloser // the reader is created on first read with the final limit.
+}
+
+func newBodyLimiter(w http.ResponseWriter, r *http.Request, limit int64) *bodyLimiter {
+	return &bodyLimiter{
+		w:             w,
+		body:          r.Body,
+		contentLength: r.ContentLength,
+		limit:         limit,
+	}
+}
+
+// Read the body, *http.MaxBytesError will be returned if the body exceeds the limit.
+// The body whose Content-Length exceeds the limit is rejected without reading it.
+func (b *bodyLimiter) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		if b.limit > 0 && b.contentLength > b.limit {
+			if b.w != nil {
+				b.w.Header().Set("Connection", "close")
+			}
+			return 0, &http.MaxBytesError{Limit: b.limit}
+		}
+		if b.limit > 0 {
+			b.reader = http.MaxBytesReader(b.w, b.body, b.limit)
+		} else {
+			b.reader = b.body
+		}
+	}
+	return b.reader.Read(p)
+}
+
+func (b *bodyLimiter) Close() error {
+	return b.body.Close()
+}
+
+// Change the limit, n <= 0 means unlimited. It fails if the body has been read.
+func (b *bodyLimiter) setLimit(n int64) error {
+	if b.reader != nil {
+		return errBodyConsumed
+	}
+	b.limit = n
+	return nil
+}
+
+// Returns the limiter of request's body, it is created with the response's writer if the request
+// was not dispatched by the application.
+func (ctx *Context) bodyLimiter() *bodyLimiter {
+	r := ctx.Request.Request
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	limiter, ok := r.Body.(*bodyLimiter)
+	if !ok {
+		limiter = newBodyLimiter(ctx.Response.writer, r, 0)
+		r.Body = limiter
+	}
+	return limiter
+}
+
+// Limit the size of request's body, n <= 0 means unlimited.
+// The error of reading the body will be *http.MaxBytesError if the body or its Content-Length exceeds the limit,
+// and it is treated as request entity too large by Bind and the form's parsing.
+// It should be called before the body is consumed, such as in middlewares, otherwise an error will be returned.
+func (ctx *Context) SetMaxBodySize(n int64) error {
+	if limiter := ctx.bodyLimiter(); limiter != nil {
+		return limiter.setLimit(n)
+	}
+	return nil
+}
+
+// Lower the limit of request's body to n before the body is consumed, n <= 0 means no change.
+func (r *Request) limitBody(n int64) error {
+	if n <= 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	limiter, ok := r.Body.(*bodyLimiter)
+	if !ok {
+		r.Body = newBodyLimiter(nil, r.Request, n)
+		return nil
+	}
+	if limiter.limit > 0 && limiter.limit <= n {
+		return nil
+	}
+	return limiter.setLimit(n)
+}
@@ -17,11 +17,12 @@ import (
 )
 
 var (
-	apps          Applications
-	Configuration *Config
-	defaultApp    *Application
-	goPath        string
-	srcPath       string
+	apps           Applications
+	Configuration  *Config
+	defaultApp     *Application
+	preMiddlewares []Middleware // the pre-router middlewares shared by all applications.
+	goPath         string
+	srcPath        string
 )
 
 func init() {
@@ -40,6 +41,10 @@ func init() {
 		serverProtocol: "HTTP",
 		serverCertFile: "",
 		serverKeyFile:  "",
+		// Unlimited.
+		serverMaxBodySize: 0,
+		// No trusted proxies.
+		serverTrustedProxies: nil,
 
 		// Controller configuration
 		controllerPrefix: "",
@@ -106,6 +111,7 @@ func init() {
 	}
 
 	apps = make(Applications, 0)
+	preMiddlewares = make([]Middleware, 0)
 }
 
 func LoadConfig(filename string) {
@@ -262,6 +268,17 @@ func SetDefaultApp(app *Application) {
 	defaultApp = app
 }
 
+// Set the pre-router middlewares shared by all applications.
+// They run before the application's pre-router middlewares, see also Application.SetPreMiddlewares.
+func SetPreMiddlewares(middlewares []Middleware) {
+	preMiddlewares = middlewares
+}
+
+// Add a pre-router middleware shared by all applications, see also SetPreMiddlewares.
+func AddPreMiddleware(middleware Middleware) {
+	preMiddlewares = append(preMiddlewares, middleware)
+}
+
 func Close() {
 	if Configuration.enableCache {
 		Configuration.cache.GetPool().Close()
@@ -276,8 +293,16 @@ func Run() {
 		defaultApp = NewApplication()
 	}
 
+	// The default application may not be one of the apps, such as the one set by SetDefaultApp.
+	defaultAppRan := false
 	for _, app := range apps {
 		app.Run()
+		if app == defaultApp {
+			defaultAppRan = true
+		}
+	}
+	if !defaultAppRan {
+		defaultApp.Run()
 	}
 
 	fmt.Printf("Application started.\n")
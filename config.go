@@ -26,6 +26,10 @@ type Config struct {
 	serverProtocol string
 	serverCertFile string
 	serverKeyFile  string
+	// The max size of request's body in bytes, zero means unlimited.
+	serverMaxBodySize int64
+	// The trusted proxies whose forwarding headers are honored.
+	serverTrustedProxies TrustedProxies
 
 	// Controller Configuration
 	controllerPrefix string
@@ -131,6 +135,18 @@ func (c *Config) Load(filename string) {
 	if err == nil {
 		c.serverKeyFile = serverKeyFile
 	}
+	serverMaxBodySize, err := section.GetInt("server.max_body_size")
+	if (err == nil) && (serverMaxBodySize >= 0) {
+		c.serverMaxBodySize = int64(serverMaxBodySize)
+	}
+	serverTrustedProxies, err := section.GetString("server.trusted_proxies")
+	if err == nil {
+		trustedProxies, err := ParseTrustedProxies(strings.Split(serverTrustedProxies, ",")...)
+		if err != nil {
+			panic(err)
+		}
+		c.serverTrustedProxies = trustedProxies
+	}
 
 	// Get controller configuration.
 	controllerPrefix, err := section.GetString("controller.prefix")
@@ -331,6 +347,14 @@ func (c *Config) ServerKeyFile() string {
 	return c.serverKeyFile
 }
 
+func (c *Config) ServerMaxBodySize() int64 {
+	return c.serverMaxBodySize
+}
+
+func (c *Config) ServerTrustedProxies() TrustedProxies {
+	return c.serverTrustedProxies
+}
+
 func (c *Config) ControllerPrefix() string {
 	return c.controllerPrefix
 }
@@ -0,0 +1,192 @@
+package clevergo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Scope of service.
+type Scope int
+
+const (
+	ScopeSingleton Scope = iota // the service is created once and shared by all requests.
+	ScopeRequest                // the service is created once per request.
+)
+
+// The tag of controller's field which should be injected, for example:
+//
+//	type UserController struct {
+//		clevergo.WebController
+//		Users UserRepository `inject:""`
+//	}
+const injectTag = "inject"
+
+type serviceProvider struct {
+	scope   Scope
+	factory reflect.Value // the service's factory, it is invalid if the value is given.
+	mu      sync.Mutex
+	created bool          // whether the singleton has been created.
+	value   reflect.Value // the singleton's value.
+}
+
+// Container holds the services which can be injected into controller's fields and action's parameters.
+type Container struct {
+	providers map[reflect.Type]*serviceProvider
+}
+
+func NewContainer() *Container {
+	return &Container{
+		providers: make(map[reflect.Type]*serviceProvider, 0),
+	}
+}
+
+// Register a singleton service by its type.
+func (c *Container) Singleton(service interface{}) {
+	v := reflect.ValueOf(service)
+	c.providers[v.Type()] = &serviceProvider{scope: ScopeSingleton, value: v}
+}
+
+// Register a singleton service as an interface.
+// The iface should be a nil pointer of interface, for example, (*UserRepository)(nil).
+func (c *Container) SingletonAs(iface interface{}, service interface{}) {
+	t := reflect.TypeOf(iface).Elem()
+	if !reflect.TypeOf(service).Implements(t) {
+		panic(fmt.Sprintf("The service %T does not implement %s.", service, t))
+	}
+	v := reflect.New(t).Elem()
+	v.Set(reflect.ValueOf(service))
+	c.providers[t] = &serviceProvider{scope: ScopeSingleton, value: v}
+}
+
+// Register a service's factory with the scope, and the service is registered as type T.
+// The singleton's factory should be func() T or func() (T, error), it is invoked on first use,
+// and it will be invoked again on next use if it returns an error.
+// The request scoped service's factory should be func(*Context) T or func(*Context) (T, error).
+func (c *Container) Provide(factory interface{}, scope Scope) {
+	fv := reflect.ValueOf(factory)
+	ft := fv.Type()
+	if scope == ScopeSingleton {
+		if ft.Kind() != reflect.Func || ft.NumIn() != 0 || !isFactoryOut(ft) {
+			panic(fmt.Sprintf("The singleton's factory is invalid: %s, it should be func() T or func() (T, error).", ft))
+		}
+	} else if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.In(0) != contextType || !isFactoryOut(ft) {
+		panic(fmt.Sprintf("The factory is invalid: %s, it should be func(*Context) T or func(*Context) (T, error).", ft))
+	}
+	c.providers[ft.Out(0)] = &serviceProvider{scope: scope, factory: fv}
+}
+
+// Returns a boolean indicating whether the factory returns T or (T, error).
+func isFactoryOut(ft reflect.Type) bool {
+	return ft.NumOut() == 1 || (ft.NumOut() == 2 && ft.Out(1) == errorType)
+}
+
+// Returns a boolean indicating whether the type was registered.
+func (c *Container) Has(t reflect.Type) bool {
+	_, ok := c.providers[t]
+	return ok
+}
+
+// Resolve the service of the type, the request scoped services are cached in the context.
+func (c *Container) resolve(ctx *Context, t reflect.Type) (reflect.Value, error) {
+	provider, ok := c.providers[t]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("The service %s was not registered.", t)
+	}
+
+	if !provider.factory.IsValid() {
+		return provider.value, nil
+	}
+
+	if provider.scope == ScopeSingleton {
+		provider.mu.Lock()
+		defer provider.mu.Unlock()
+		if !provider.created {
+			v, err := provider.create(nil)
+			if err != nil {
+				return v, err
+			}
+			provider.value, provider.created = v, true
+		}
+		return provider.value, nil
+	}
+
+	if v, ok := ctx.services[t]; ok {
+		return v, nil
+	}
+	v, err := provider.create(ctx)
+	if err != nil {
+		return v, err
+	}
+	if ctx.services == nil {
+		ctx.services = make(map[reflect.Type]reflect.Value, 0)
+	}
+	ctx.services[t] = v
+	return v, nil
+}
+
+// Invoke the factory, the context is not passed to the singleton's factory.
+func (p *serviceProvider) create(ctx *Context) (reflect.Value, error) {
+	var args []reflect.Value
+	if p.scope != ScopeSingleton {
+		args = []reflect.Value{reflect.ValueOf(ctx)}
+	}
+	values := p.factory.Call(args)
+	if len(values) == 2 {
+		if err, _ := values[1].Interface().(error); err != nil {
+			return values[0], err
+		}
+	}
+	return values[0], nil
+}
+
+// The controller's field which should be injected.
+type fieldInjection struct {
+	index int
+	t     reflect.Type
+}
+
+// Get the injections of controller's fields those are tagged by "inject",
+// the error will be returned if the field is unexported or the service was not registered.
+func controllerInjections(c *Container, t reflect.Type) ([]fieldInjection, error) {
+	injections := make([]fieldInjection, 0)
+	missing := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup(injectTag); !ok {
+			continue
+		}
+		if len(field.PkgPath) > 0 {
+			return nil, fmt.Errorf("The field %s.%s cannot be injected, it is unexported.", t.Name(), field.Name)
+		}
+		if !c.Has(field.Type) {
+			missing = append(missing, fmt.Sprintf("%s.%s(%s)", t.Name(), field.Name, field.Type))
+			continue
+		}
+		injections = append(injections, fieldInjection{index: i, t: field.Type})
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("The services of fields were not registered: %s.", strings.Join(missing, ", "))
+	}
+
+	return injections, nil
+}
+
+// Resolve the value of type which is pointed to by v, v should be a pointer, for example:
+//
+//	var users UserRepository
+//	err := ctx.Resolve(&users)
+func (ctx *Context) Resolve(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("The destination should be a non-nil pointer.")
+	}
+	service, err := ctx.app.container.resolve(ctx, rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(service)
+	return nil
+}
@@ -0,0 +1,80 @@
+package clevergo
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type counter struct {
+	n int
+}
+
+func TestContainerScopes(t *testing.T) {
+	app := NewApplication()
+	created := 0
+	app.Container().Provide(func(ctx *Context) *counter {
+		created++
+		return &counter{created}
+	}, ScopeRequest)
+
+	ct := reflect.TypeOf(&counter{})
+	for i := 1; i <= 2; i++ {
+		ctx := acquireContext(app, httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+		first, _ := app.container.resolve(ctx, ct)
+		second, _ := app.container.resolve(ctx, ct)
+		if first.Interface() != second.Interface() {
+			t.Errorf("the request scoped service should be shared in the same request")
+		}
+		if n := first.Interface().(*counter).n; n != i {
+			t.Errorf("request %d: service was created %d times", i, n)
+		}
+		releaseContext(ctx)
+	}
+}
+
+func TestContainerSingletonFactory(t *testing.T) {
+	c := NewContainer()
+	calls := 0
+	c.Provide(func() (*counter, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("unavailable")
+		}
+		return &counter{calls}, nil
+	}, ScopeSingleton)
+
+	ct := reflect.TypeOf(&counter{})
+	if _, err := c.resolve(nil, ct); err == nil {
+		t.Fatal("the factory's error should be returned")
+	}
+	// The error is not cached, the factory is invoked again.
+	first, err := c.resolve(nil, ct)
+	if err != nil {
+		t.Fatalf("resolve() returns error after the factory recovered: %s", err)
+	}
+	second, _ := c.resolve(nil, ct)
+	if first.Interface() != second.Interface() || calls != 2 {
+		t.Errorf("the singleton should be created once after succeeded, the factory was invoked %d times", calls)
+	}
+}
+
+type injectedController struct {
+	Counter *counter `inject:""`
+	Missing *greeter `inject:""`
+}
+
+func TestControllerInjectionsMissing(t *testing.T) {
+	c := NewContainer()
+	c.Singleton(&counter{})
+	if _, err := controllerInjections(c, reflect.TypeOf(injectedController{})); err == nil {
+		t.Errorf("the missing service should be reported")
+	}
+
+	c.Singleton(&greeter{})
+	injections, err := controllerInjections(c, reflect.TypeOf(injectedController{}))
+	if err != nil || len(injections) != 2 {
+		t.Errorf("controllerInjections() = %v, %v, expected 2 injections", injections, err)
+	}
+}
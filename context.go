@@ -1,30 +1,49 @@
 package clevergo
 
 import (
+	"context"
 	"fmt"
 	"github.com/clevergo/jwt"
 	"github.com/clevergo/log"
 	"github.com/clevergo/session"
 	"github.com/julienschmidt/httprouter"
+	"io"
 	"net/http"
+	"reflect"
 	"strconv"
-	"strings"
+	"sync"
 )
 
 type Context struct {
-	app             *Application                // Application.
-	Response        *Response                   // Response.
-	Request         *Request                    // Request.
-	Params          Params                      // URL params.
-	Session         *session.Session            // Session.
-	Log             *log.Log                    // Log.
-	Values          map[interface{}]interface{} // Share data of middlewares.
-	Token           *jwt.Token                  // JWT(JSON WEB TOKEN).
-	SkipMiddlewares SkipMiddlewares             // List of middlewares those can be skip.
+	app             *Application                   // Application.
+	Response        *Response                      // Response.
+	Request         *Request                       // Request.
+	Params          Params                         // URL params.
+	Session         *session.Session               // Session.
+	Log             *log.Log                       // Log.
+	Values          map[interface{}]interface{}    // Share data of middlewares.
+	Token           *jwt.Token                     // JWT(JSON WEB TOKEN).
+	SkipMiddlewares SkipMiddlewares                // List of middlewares those can be skip.
+	problem         bool                           // whether the errors are rendered as problem details.
+	action          Action                         // current action, it is nil if the request was not routed to an action.
+	services        map[reflect.Type]reflect.Value // the request scoped services.
+	httpError       *HTTPError                     // the rendered error.
+	cancels         []context.CancelFunc           // release the resources of request, such as deadlines, they are called on releasing.
 }
 
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		return &Context{
+			Response: &Response{},
+			Request:  &Request{},
+			Values:   make(map[interface{}]interface{}, 0),
+		}
+	},
+}
+
+// Create a context.
+// The request's form is not parsed here, it will be parsed on first access, such as FormValue.
 func NewContext(app *Application, rw http.ResponseWriter, r *http.Request, params httprouter.Params) *Context {
-	r.ParseForm()
 	return &Context{
 		app:             app,
 		Response:        NewResponse(rw),
@@ -36,6 +55,81 @@ func NewContext(app *Application, rw http.ResponseWriter, r *http.Request, param
 	}
 }
 
+// Get a context from the pool, it should be released by releaseContext after the response was flushed.
+func acquireContext(app *Application, rw http.ResponseWriter, r *http.Request, params httprouter.Params) *Context {
+	ctx := contextPool.Get().(*Context)
+	ctx.reset(app, rw, r, params)
+	return ctx
+}
+
+// Put the context back to the pool.
+// The context and its response and request must not be referenced after releasing.
+func releaseContext(ctx *Context) {
+	// Remove the temporary files of multipart form.
+	if ctx.Request.Request != nil && ctx.Request.MultipartForm != nil {
+		ctx.Request.MultipartForm.RemoveAll()
+	}
+
+	// Release the resources of request, such as deadlines.
+	for _, cancel := range ctx.cancels {
+		cancel()
+	}
+
+	ctx.reset(nil, nil, nil, nil)
+	contextPool.Put(ctx)
+}
+
+func (ctx *Context) reset(app *Application, rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx.app = app
+	ctx.Response.reset(rw)
+	ctx.Request.Request = r
+	if app != nil {
+		ctx.Request.uploadOptions = app.uploadOptions
+		ctx.Response.sendfile = app.sendfileOptions
+		ctx.Request.trustedProxies = app.TrustedProxies()
+	} else {
+		ctx.Request.uploadOptions = NewUploadOptions()
+		ctx.Response.sendfile = NewSendfileOptions()
+		ctx.Request.trustedProxies = nil
+	}
+	ctx.Request.formErr = nil
+	ctx.Params = NewParams(params)
+	ctx.Session = nil
+	ctx.Log = nil
+	// The map of pooled context is reused, it is cleared instead of reallocating.
+	if ctx.Values == nil {
+		ctx.Values = make(map[interface{}]interface{}, 0)
+	}
+	for k := range ctx.Values {
+		delete(ctx.Values, k)
+	}
+	ctx.Token = nil
+	ctx.SkipMiddlewares = nil
+	ctx.problem = false
+	ctx.action = nil
+	ctx.services = nil
+	ctx.httpError = nil
+	ctx.cancels = ctx.cancels[:0]
+}
+
+// Returns current action, nil will be returned if the request was not routed to an action.
+func (ctx *Context) Action() Action {
+	return ctx.action
+}
+
+// Returns the shared value by key, nil will be returned if the key does not exist.
+func (ctx *Context) Value(key interface{}) interface{} {
+	return ctx.Values[key]
+}
+
+// Set the shared value.
+func (ctx *Context) SetValue(key, value interface{}) {
+	if ctx.Values == nil {
+		ctx.Values = make(map[interface{}]interface{}, 0)
+	}
+	ctx.Values[key] = value
+}
+
 func (ctx *Context) JWT() *jwt.JWT {
 	return ctx.app.jwt
 }
@@ -49,15 +143,75 @@ func (ctx *Context) GetSession() error {
 	return err
 }
 
+// Render the error by the application's error handler, the response's body will be discarded.
+// The error which is not an HTTPError is treated as internal server error.
+// The errors of restful controllers are rendered by Problem, see also ProblemOptions.
+func (ctx *Context) Error(err error) {
+	if ctx.problem {
+		ctx.Problem(err)
+		return
+	}
+
+	e := ToHTTPError(err)
+	ctx.httpError = e
+	if ctx.Response.streaming {
+		// The status and headers have been sent, the error can not be rendered.
+		return
+	}
+	ctx.Response.discardBody()
+
+	if ctx.app != nil && ctx.app.errorHandler != nil {
+		ctx.app.errorHandler(ctx, e)
+	} else {
+		DefaultErrorHandler(ctx, e)
+	}
+}
+
+// Render the error as problem details by the application's problem handler,
+// the response's body will be discarded.
+func (ctx *Context) Problem(err error) {
+	e := ToHTTPError(err)
+	ctx.httpError = e
+	if ctx.Response.streaming {
+		// The status and headers have been sent, the error can not be rendered.
+		return
+	}
+	ctx.Response.discardBody()
+
+	if ctx.app != nil && ctx.app.problemHandler != nil {
+		ctx.app.problemHandler(ctx, e)
+	} else {
+		DefaultProblemHandler(ctx, e)
+	}
+}
+
 func (ctx *Context) Flush() {
-	if err := recover(); err != nil {
-		ctx.app.panicHandler(ctx.Response.writer, ctx.Request.Request, err)
-	} else if !ctx.Response.cancel {
+	if v := recover(); v != nil {
+		if ctx.app != nil && ctx.app.panicHandler != nil {
+			ctx.app.panicHandler(ctx.Response.writer, ctx.Request.Request, v)
+			return
+		}
+		// The error is not sent if the response has been sent or taken over, such as Redirect.
+		ctx.Error(panicError(v))
+	}
+
+	if !ctx.Response.cancel && !ctx.Response.streaming {
+		// send response body, the seekable content and the body's reader take precedence over the buffered body.
+		if c := ctx.Response.content; c != nil {
+			http.ServeContent(ctx.Response.writer, ctx.Request.Request, c.name, c.modtime, c.reader)
+			ctx.Response.discardBody()
+			return
+		}
+
 		// send response status and headers.
 		ctx.Response.writer.WriteHeader(ctx.Response.status)
 
-		// send response body.
-		fmt.Fprint(ctx.Response.writer, ctx.Response.body)
+		if ctx.Response.reader != nil {
+			io.Copy(ctx.Response.writer, ctx.Response.reader)
+			ctx.Response.discardBody()
+		} else {
+			fmt.Fprint(ctx.Response.writer, ctx.Response.body)
+		}
 	}
 }
 
@@ -89,12 +243,9 @@ func (ps Params) Int(name string) (int, error) {
 }
 
 // Returns param's boolean value by name.
-// Returns true if the param's value is equal to "true"(case insensitive) or nonzero,
+// Returns true if the param's value is a true value of ParseBool, such as "1" and "true"(case insensitive),
 // Otherwise returns false.
 func (ps Params) Bool(name string) bool {
-	value := ps.Params.ByName(name)
-	if len(value) == 0 {
-		return false
-	}
-	return strings.EqualFold(value, "true") || (value != "0")
+	value, _ := ParseBool(ps.Params.ByName(name))
+	return value
 }
@@ -0,0 +1,65 @@
+package clevergo
+
+import (
+	"context"
+	"errors"
+	"github.com/garyburd/redigo/redis"
+	"net/http"
+	"time"
+)
+
+var errCacheDisabled = errors.New("the cache is disabled")
+
+// Returns the request's context, it is canceled when the client's connection closes, the request is canceled,
+// or the deadline set by SetDeadline or SetTimeout exceeds. It should be passed to the functions which accept
+// context.Context, such as http.NewRequestWithContext, rather than the Context itself, since the Context
+// is pooled and reused after the request was handled.
+func (ctx *Context) Ctx() context.Context {
+	if ctx.Request == nil || ctx.Request.Request == nil {
+		return context.Background()
+	}
+	return ctx.Request.Context()
+}
+
+// Convert the error of request's context to HTTPError with status service unavailable.
+func contextError(err error) error {
+	return WrapError(http.StatusServiceUnavailable, err)
+}
+
+// Set the deadline of the request, the earlier deadline takes precedence.
+// It should be called before the request is handled, such as in middlewares, see also DeadlineMiddleware.
+func (ctx *Context) SetDeadline(deadline time.Time) {
+	c, cancel := context.WithDeadline(ctx.Ctx(), deadline)
+	ctx.cancels = append(ctx.cancels, cancel)
+	ctx.Request.Request = ctx.Request.WithContext(c)
+}
+
+// Set the deadline of the request as now plus timeout, see also SetDeadline.
+func (ctx *Context) SetTimeout(timeout time.Duration) {
+	ctx.SetDeadline(time.Now().Add(timeout))
+}
+
+// Executes the redis command by the application's cache, it respects the context's cancellation and deadline:
+// the error of context will be returned if the context was done, and the command's read timeout
+// is limited by the deadline.
+func (ctx *Context) CacheDo(cmd string, args ...interface{}) (interface{}, error) {
+	c := ctx.Ctx()
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+	if ctx.app == nil || ctx.app.cache == nil {
+		return nil, errCacheDisabled
+	}
+
+	conn := ctx.app.cache.GetConn()
+	defer conn.Close()
+
+	if deadline, ok := c.Deadline(); ok {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+		return redis.DoWithTimeout(conn, timeout, cmd, args...)
+	}
+	return conn.Do(cmd, args...)
+}
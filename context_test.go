@@ -0,0 +1,227 @@
+package clevergo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBenchFormRequest() *http.Request {
+	r, _ := http.NewRequest("POST", "/", strings.NewReader("name=clevergo&tags=web&tags=framework"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// Allocate a context and parse the form eagerly on every request, it is used for comparison.
+func BenchmarkNewContextParseForm(b *testing.B) {
+	app := NewApplication()
+	rw := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := newBenchFormRequest()
+		r.ParseForm()
+		NewContext(app, rw, r, nil)
+	}
+}
+
+func BenchmarkAcquireContext(b *testing.B) {
+	app := NewApplication()
+	rw := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := newBenchFormRequest()
+		ctx := acquireContext(app, rw, r, nil)
+		releaseContext(ctx)
+	}
+}
+
+func TestContextValues(t *testing.T) {
+	ctx := acquireContext(NewApplication(), httptest.NewRecorder(), newBenchFormRequest(), nil)
+	if v := ctx.Value("key"); v != nil {
+		t.Errorf("Value(\"key\") = %v, expected nil", v)
+	}
+	ctx.SetValue("key", "value")
+	if v := ctx.Value("key"); v != "value" {
+		t.Errorf("Value(\"key\") = %v, expected \"value\"", v)
+	}
+	// The existing code writes the map directly.
+	ctx.Values["direct"] = true
+	if v := ctx.Value("direct"); v != true {
+		t.Errorf("Value(\"direct\") = %v, expected true", v)
+	}
+	if ctx.Request.Form != nil {
+		t.Errorf("form should not be parsed before it was accessed")
+	}
+	if name := ctx.Request.FormValue("name"); name != "clevergo" {
+		t.Errorf("FormValue(\"name\") = %q, expected \"clevergo\"", name)
+	}
+
+	releaseContext(ctx)
+	if len(ctx.Values) != 0 || ctx.Request.Request != nil {
+		t.Errorf("context should be reset after releasing")
+	}
+}
+
+func TestContextFlushPanic(t *testing.T) {
+	tests := []struct {
+		v      interface{}
+		status int
+	}{
+		{NewHTTPError(http.StatusForbidden, "No permission."), http.StatusForbidden},
+		{"unexpected", http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		func() {
+			ctx := acquireContext(NewApplication(), rw, newBenchFormRequest(), nil)
+			defer ctx.Flush()
+			ctx.Response.SetBody("discarded")
+			panic(test.v)
+		}()
+		if rw.Code != test.status {
+			t.Errorf("panic(%v): status = %d, expected %d", test.v, rw.Code, test.status)
+		}
+		if strings.Contains(rw.Body.String(), "discarded") {
+			t.Errorf("panic(%v): the response's body should be discarded", test.v)
+		}
+	}
+}
+
+type contextKey string
+
+func TestContextDeadline(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), contextKey("request"), "bar"))
+	ctx := acquireContext(nil, httptest.NewRecorder(), r, nil)
+	defer releaseContext(ctx)
+
+	ctx.SetValue(contextKey("foo"), "foo")
+	if value := ctx.Value(contextKey("foo")); value != "foo" {
+		t.Errorf("Value(foo) = %v, expected foo", value)
+	}
+
+	if _, ok := ctx.Ctx().Deadline(); ok {
+		t.Error("the context has a deadline before SetTimeout")
+	}
+	ctx.SetTimeout(10 * time.Millisecond)
+	c := ctx.Ctx()
+	if _, ok := c.Deadline(); !ok {
+		t.Error("the context has no deadline after SetTimeout")
+	}
+	if value := c.Value(contextKey("request")); value != "bar" {
+		t.Errorf("Value(request) = %v, expected the request's value bar", value)
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the context is not done after the deadline")
+	}
+	if c.Err() != context.DeadlineExceeded {
+		t.Errorf("Err() = %v, expected %v", c.Err(), context.DeadlineExceeded)
+	}
+	if _, err := ctx.CacheDo("GET", "foo"); err != context.DeadlineExceeded {
+		t.Errorf("CacheDo() returns %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	// The view should not be rendered after the deadline.
+	wc := &WebController{Context: ctx}
+	wc.RenderFile("index")
+	if status := ctx.Response.status; status != http.StatusServiceUnavailable {
+		t.Errorf("status of RenderFile() after the deadline = %d, expected %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestResponseStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := acquireContext(nil, w, httptest.NewRequest("GET", "/", nil), nil)
+	ctx.Response.SetStatus(http.StatusCreated)
+	ctx.Response.SetBody("buffered")
+	err := ctx.Response.Stream(func(w io.Writer) error {
+		io.WriteString(w, "foo")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "bar")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Flushed {
+		t.Error("the response is not flushed")
+	}
+	ctx.Response.SetStatus(http.StatusOK)
+	ctx.Error(NewHTTPError(http.StatusInternalServerError, "ignored"))
+	ctx.Flush()
+	releaseContext(ctx)
+
+	if w.Code != http.StatusCreated || w.Body.String() != "foobar" {
+		t.Errorf("response = %d %q, expected %d %q", w.Code, w.Body.String(), http.StatusCreated, "foobar")
+	}
+
+	w = httptest.NewRecorder()
+	ctx = acquireContext(nil, w, httptest.NewRequest("GET", "/", nil), nil)
+	ctx.Response.SetBody("buffered")
+	ctx.Response.SetBodyReader(strings.NewReader("reader"))
+	ctx.Flush()
+	releaseContext(ctx)
+	if w.Body.String() != "reader" {
+		t.Errorf("body = %q, expected %q", w.Body.String(), "reader")
+	}
+}
+
+func TestContextSSE(t *testing.T) {
+	r := httptest.NewRequest("GET", "/events", nil)
+	r.Header.Set("Last-Event-ID", "41")
+	w := httptest.NewRecorder()
+	ctx := acquireContext(nil, w, r, nil)
+
+	sse := ctx.SSE()
+	if sse.LastEventID() != "41" {
+		t.Errorf("LastEventID() = %q, expected 41", sse.LastEventID())
+	}
+	sse.Retry(3 * time.Second)
+	sse.Send("message", "42", "hello\nworld")
+	sse.Send("", "", map[string]int{"count": 1})
+	// The lone CR is a line break, it should not inject the fields.
+	sse.Send("", "", "a\rid: 0\r\nb")
+	ctx.Error(NewHTTPError(http.StatusInternalServerError, ""))
+	ctx.Flush()
+	releaseContext(ctx)
+
+	if err := sse.Comment("late"); err == nil {
+		t.Error("the writer is not closed after releasing context")
+	}
+	expected := "retry: 3000\n\nid: 42\nevent: message\ndata: hello\ndata: world\n\ndata: {\"count\":1}\n\ndata: a\ndata: id: 0\ndata: b\n\n"
+	if w.Body.String() != expected {
+		t.Errorf("body = %q, expected %q", w.Body.String(), expected)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+}
+
+var errShared = NewHTTPError(http.StatusConflict, "")
+
+func TestContextFlushPanicAfterRedirect(t *testing.T) {
+	rw := httptest.NewRecorder()
+	func() {
+		ctx := acquireContext(NewApplication(), rw, httptest.NewRequest("GET", "/", nil), nil)
+		defer releaseContext(ctx)
+		defer ctx.Flush()
+		ctx.Redirect("/login")
+		panic(errShared)
+	}()
+	if rw.Code != http.StatusFound || strings.Contains(rw.Body.String(), "Conflict") {
+		t.Errorf("status = %d with body %q, expected the redirect only", rw.Code, rw.Body.String())
+	}
+	if errShared.Stack() != nil {
+		t.Error("the stack should not be attached to the shared error")
+	}
+}
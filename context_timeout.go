@@ -0,0 +1,185 @@
+package clevergo
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Handle the request by handler with the timeout, the request's context will be done after the timeout.
+// The handler runs on a copy of the context in another goroutine, its response is buffered and merged
+// into the context if it finishes in time, and its panic is propagated with the stack.
+//
+// Otherwise the error of request's context is returned, such as context.DeadlineExceeded, and the writes
+// of the handler are discarded. The copy takes over the request's resources, such as the temporary files
+// of multipart form and the session, and releases them after the handler returns, since the context may be
+// released and reused before then, so that the context's session is nil after timeout.
+// The copy has its own Log which is flushed after the handler returns.
+// See also TimeoutMiddleware of the middleware package.
+func (ctx *Context) HandleTimeout(timeout time.Duration, handler Handler) error {
+	ctx.SetTimeout(timeout)
+
+	tw := newTimeoutWriter(ctx.Response.writer.Header())
+	shadow := ctx.fork(tw)
+	inherited := len(shadow.cancels)
+	ownLog := shadow.Log != ctx.Log
+
+	done := make(chan struct{})
+	var panicErr *HTTPError
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				// Capture the stack here, it is lost after the panic is propagated to the caller's goroutine.
+				panicErr = panicError(v)
+			}
+			close(done)
+		}()
+		handler.Handle(shadow)
+	}()
+
+	select {
+	case <-done:
+		if ownLog {
+			shadow.Log.Flush()
+		}
+		if panicErr != nil {
+			// Take over the copy's resources, the error will be rendered instead of its response.
+			ctx.Request.Request = shadow.Request.Request
+			ctx.cancels = shadow.cancels
+			ctx.Session = shadow.Session
+			panic(panicErr)
+		}
+		ctx.merge(shadow, tw)
+		return nil
+	case <-ctx.Ctx().Done():
+		tw.timeout()
+		ctx.Request.MultipartForm = nil
+		ctx.Session = nil
+		go func() {
+			<-done
+			if ownLog {
+				shadow.Log.Flush()
+			}
+			if shadow.Request.MultipartForm != nil {
+				shadow.Request.MultipartForm.RemoveAll()
+			}
+			for _, cancel := range shadow.cancels[inherited:] {
+				cancel()
+			}
+		}()
+		return ctx.Ctx().Err()
+	}
+}
+
+// Returns a copy of the context whose response writes to w, the copy is used by only one goroutine.
+func (ctx *Context) fork(w http.ResponseWriter) *Context {
+	shadow := *ctx
+	shadow.Response = &Response{
+		writer:    w,
+		status:    ctx.Response.status,
+		body:      ctx.Response.body,
+		reader:    ctx.Response.reader,
+		content:   ctx.Response.content,
+		sendfile:  ctx.Response.sendfile,
+		cancel:    ctx.Response.cancel,
+		streaming: ctx.Response.streaming,
+	}
+	// The copy has its own request, so that the request of context can be released independently.
+	request := *ctx.Request
+	request.Request = ctx.Request.WithContext(ctx.Ctx())
+	shadow.Request = &request
+	shadow.cancels = append([]context.CancelFunc(nil), ctx.cancels...)
+	// The log is used by both of the context and the copy after timeout.
+	if ctx.Log != nil && ctx.app != nil && ctx.app.logger != nil {
+		shadow.Log = ctx.app.logger.NewLog()
+	}
+	if ctx.Values != nil {
+		shadow.Values = make(map[interface{}]interface{}, len(ctx.Values))
+		for k, v := range ctx.Values {
+			shadow.Values[k] = v
+		}
+	}
+	if ctx.services != nil {
+		shadow.services = make(map[reflect.Type]reflect.Value, len(ctx.services))
+		for k, v := range ctx.services {
+			shadow.services[k] = v
+		}
+	}
+	return &shadow
+}
+
+// Merge the state of the finished copy which is created by fork, the buffered headers and writes are sent.
+func (ctx *Context) merge(shadow *Context, tw *timeoutWriter) {
+	response, request, writer, l := ctx.Response, ctx.Request, ctx.Response.writer, ctx.Log
+	*response = *shadow.Response
+	response.writer = writer
+	*request = *shadow.Request
+	*ctx = *shadow
+	ctx.Response, ctx.Request, ctx.Log = response, request, l
+
+	header := ctx.Response.writer.Header()
+	for k := range header {
+		delete(header, k)
+	}
+	for k, v := range tw.header {
+		header[k] = v
+	}
+	if tw.code != 0 {
+		ctx.Response.writer.WriteHeader(tw.code)
+		ctx.Response.writer.Write(tw.buf.Bytes())
+	}
+}
+
+// timeoutWriter buffers the headers and writes of the action, the writes are discarded after timeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func newTimeoutWriter(header http.Header) *timeoutWriter {
+	tw := &timeoutWriter{
+		header: make(http.Header, len(header)),
+	}
+	for k, v := range header {
+		tw.header[k] = append([]string(nil), v...)
+	}
+	return tw
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write to the buffer, http.ErrHandlerTimeout will be returned after timeout.
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	tw.timedOut = true
+	tw.mu.Unlock()
+}
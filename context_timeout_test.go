@@ -0,0 +1,103 @@
+package clevergo
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/log"
+	"github.com/clevergo/session"
+)
+
+func timeoutPanic(ctx *Context) {
+	panic("timeout panic")
+}
+
+func TestHandleTimeoutPanic(t *testing.T) {
+	ctx := acquireContext(nil, httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	defer releaseContext(ctx)
+
+	defer func() {
+		e, ok := recover().(*HTTPError)
+		if !ok {
+			t.Fatalf("the panic is not propagated as HTTPError: %v", e)
+		}
+		// The stack should be captured in the handler's goroutine.
+		if !strings.Contains(string(e.stack), "timeoutPanic") {
+			t.Errorf("the stack does not contain the handler:\n%s", e.stack)
+		}
+	}()
+	ctx.HandleTimeout(time.Second, HandlerFunc(timeoutPanic))
+}
+
+func TestHandleTimeoutRelease(t *testing.T) {
+	r := newUploadRequest(map[string]string{"a.txt": "content of file"})
+	ctx := acquireContext(nil, httptest.NewRecorder(), r, nil)
+	// Store the files in temporary files.
+	ctx.Request.SetUploadOptions(UploadOptions{MaxMemory: 1})
+	if _, err := ctx.Request.Files("files"); err != nil {
+		t.Fatal(err)
+	}
+
+	released := make(chan struct{})
+	result := make(chan string, 1)
+	err := ctx.HandleTimeout(10*time.Millisecond, HandlerFunc(func(shadow *Context) {
+		<-released
+		// The files are still available after the context was released.
+		file, err := shadow.Request.File("files")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer f.Close()
+		data, _ := ioutil.ReadAll(f)
+		result <- string(data)
+	}))
+	if err == nil {
+		t.Fatal("HandleTimeout() returns nil, expected the error of timeout")
+	}
+	releaseContext(ctx)
+	close(released)
+
+	if data := <-result; data != "content of file" {
+		t.Errorf("the timed out handler reads %q, expected the content of file", data)
+	}
+}
+
+// The timed out handler keeps logging and using the session while the context renders the error and flushes its log.
+func TestHandleTimeoutLogAndSession(t *testing.T) {
+	app := NewApplication()
+	app.SetLogger(log.NewLogger(log.LevelDebug, 0))
+	ctx := acquireContext(app, httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	ctx.Log = app.logger.NewLog()
+	ctx.Session = &session.Session{Values: map[string]interface{}{}}
+
+	finished := make(chan struct{})
+	err := ctx.HandleTimeout(10*time.Millisecond, HandlerFunc(func(shadow *Context) {
+		defer close(finished)
+		<-shadow.Ctx().Done()
+		for i := 0; i < 100; i++ {
+			shadow.Log.Info("still running")
+			shadow.Session.Values["count"] = i
+		}
+	}))
+	if err == nil {
+		t.Fatal("HandleTimeout() returns nil, expected the error of timeout")
+	}
+	if ctx.Session != nil {
+		t.Error("the session should be taken over by the timed out handler")
+	}
+	for i := 0; i < 100; i++ {
+		ctx.Log.Warn("timed out")
+	}
+	ctx.Log.Flush()
+	releaseContext(ctx)
+	<-finished
+}
@@ -2,13 +2,17 @@ package clevergo
 
 import (
 	"reflect"
+	"sync"
 )
 
 type WebControllerInterface interface {
 	Init(action Action, ctx *Context)
-	BeforeAction() bool
+	BeforeAction() error
+	AfterAction(result interface{}, err error) error
 	BeforeResponse()
+	Finally()
 	SkipMiddlewares() map[string]SkipMiddlewares
+	Middlewares() map[string][]Middleware
 	Actions() WebActionRoutes
 	Layout() (bool, string)
 	ViewPath() string
@@ -16,9 +20,12 @@ type WebControllerInterface interface {
 
 type RestControllerInterface interface {
 	Init(action Action, ctx *Context)
-	BeforeAction() bool
+	BeforeAction() error
+	AfterAction(result interface{}, err error) error
 	BeforeResponse()
+	Finally()
 	SkipMiddlewares() map[string]SkipMiddlewares
+	Middlewares() map[string][]Middleware
 }
 
 type ControllerInfo struct {
@@ -29,6 +36,53 @@ type ControllerInfo struct {
 	pkgPath    string
 	layout     string
 	viewsPath  string
+	pool       sync.Pool        // the pool of controller's instances.
+	injections []fieldInjection // the fields which should be injected.
+	resolved   bool             // whether the injections have been resolved.
+}
+
+// Resolve the injections of controller's fields, it is invoked once on application's running.
+func (ci *ControllerInfo) resolveInjections(c *Container) {
+	if ci.resolved {
+		return
+	}
+	injections, err := controllerInjections(c, ci.t)
+	if err != nil {
+		panic(err)
+	}
+	ci.injections = injections
+	ci.resolved = true
+}
+
+// Inject the services into controller's fields.
+func (ci *ControllerInfo) inject(c *Container, ctx *Context, cv reflect.Value) error {
+	for i := 0; i < len(ci.injections); i++ {
+		service, err := c.resolve(ctx, ci.injections[i].t)
+		if err != nil {
+			return err
+		}
+		cv.Elem().Field(ci.injections[i].index).Set(service)
+	}
+	return nil
+}
+
+// Initialize the pool of controller's instances.
+func (ci *ControllerInfo) initPool() {
+	ci.pool.New = func() interface{} {
+		return reflect.New(ci.t).Interface()
+	}
+}
+
+// Get a zero controller's instance from the pool.
+func (ci *ControllerInfo) getInstance() reflect.Value {
+	return reflect.ValueOf(ci.pool.Get())
+}
+
+// Reset the controller's instance and put it back to the pool.
+// The controller's instance should not be referenced after the action was finished.
+func (ci *ControllerInfo) putInstance(cv reflect.Value) {
+	cv.Elem().Set(reflect.Zero(ci.t))
+	ci.pool.Put(cv.Interface())
 }
 
 func (ci *ControllerInfo) FullName() string {
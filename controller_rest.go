@@ -44,19 +44,40 @@ func (rc *RestController) Info() *ControllerInfo {
 	return rc.Action.Controller()
 }
 
-func (rc *RestController) BeforeAction() bool {
-	return true
+// Returns an error to terminate the request, the error will be rendered by the application's error handler,
+// except ErrAbort.
+func (rc *RestController) BeforeAction() error {
+	return nil
 }
 
+// Invoked after the action, result is the action's return value(nil if the action returns nothing),
+// err is the action's error. The returned error replaces the action's error, and the result will not be rendered
+// if it is not nil.
+func (rc *RestController) AfterAction(result interface{}, err error) error {
+	return err
+}
+
+// Invoked before the response is sent, even if BeforeAction terminates the request or the action panics.
 func (rc *RestController) BeforeResponse() {
 }
 
+// Invoked at the end of the request, even if BeforeAction terminates the request or the action panics.
+func (rc *RestController) Finally() {
+}
+
 func (rc *RestController) SkipMiddlewares() map[string]SkipMiddlewares {
 	return map[string]SkipMiddlewares{}
 }
 
+// Get the additional middlewares of methods, the key is the upper case HTTP method, such as "POST".
+// They run after the application's middlewares.
+func (rc *RestController) Middlewares() map[string][]Middleware {
+	return map[string][]Middleware{}
+}
+
 // the v will be responsed directly if type of v is string.
-func (rc *RestController) RenderJson(v interface{}) {
+// The error will be returned if v cannot be marshaled.
+func (rc *RestController) RenderJson(v interface{}) error {
 	rc.Context.Response.SetJsonHeader()
 
 	if value, ok := v.(string); ok {
@@ -64,15 +85,17 @@ func (rc *RestController) RenderJson(v interface{}) {
 	} else {
 		json, err := json.Marshal(v)
 		if err != nil {
-			// wc.Response.InternalServerError(err.Error())
-			return
+			return err
 		}
 		rc.Context.Response.body += string(json)
 	}
+
+	return nil
 }
 
 // the v will be responsed directly if type of v is string.
-func (rc *RestController) RenderJsonp(v interface{}, callback string) {
+// The error will be returned if v cannot be marshaled.
+func (rc *RestController) RenderJsonp(v interface{}, callback string) error {
 	rc.Context.Response.SetJsonpHeader()
 
 	if value, ok := v.(string); ok {
@@ -81,15 +104,17 @@ func (rc *RestController) RenderJsonp(v interface{}, callback string) {
 
 		json, err := json.Marshal(v)
 		if err != nil {
-			// wc.Response.InternalServerError(err.Error())
-			return
+			return err
 		}
 		rc.Context.Response.body += callback + "(" + string(json) + ")"
 	}
+
+	return nil
 }
 
 // the v will be responsed directly if type of v is string.
-func (rc *RestController) RenderXml(v interface{}, header string) {
+// The error will be returned if v cannot be marshaled.
+func (rc *RestController) RenderXml(v interface{}, header string) error {
 	rc.Context.Response.SetXmlHeader()
 
 	if value, ok := v.(string); ok {
@@ -97,8 +122,7 @@ func (rc *RestController) RenderXml(v interface{}, header string) {
 	} else {
 		byteXML, err := xml.MarshalIndent(v, "", `   `)
 		if err != nil {
-			//wc.Response.InternalServerError(err.Error())
-			return
+			return err
 		}
 
 		if len(header) == 0 {
@@ -107,4 +131,6 @@ func (rc *RestController) RenderXml(v interface{}, header string) {
 
 		rc.Context.Response.body = header + string(byteXML)
 	}
+
+	return nil
 }
@@ -65,8 +65,14 @@ func (wc *WebController) getSession() {
 }
 
 // Save session.
+// The session will not be saved if the request's context was done, such as the client disconnected,
+// since the store does not respect the context, the dropped session is logged as a warning.
 func (wc *WebController) saveSession() {
 	if wc.Context.Session != nil {
+		if err := wc.Context.Ctx().Err(); err != nil {
+			wc.Action.App().logWarn(fmt.Sprintf("The session of %s %s was not saved: %s.", wc.Context.Request.Method, wc.Context.Request.URL.Path, err))
+			return
+		}
 		err := wc.Action.App().sessionStore.Save(wc.Context.Response.writer, wc.Context.Session)
 		if err != nil {
 			fmt.Println(err.Error())
@@ -74,14 +80,28 @@ func (wc *WebController) saveSession() {
 	}
 }
 
-func (wc *WebController) BeforeAction() bool {
-	return true
+// Returns an error to terminate the request, the error will be rendered by the application's error handler,
+// except ErrAbort.
+func (wc *WebController) BeforeAction() error {
+	return nil
+}
+
+// Invoked after the action, result is the action's return value(nil if the action returns nothing),
+// err is the action's error. The returned error replaces the action's error, and the result will not be rendered
+// if it is not nil.
+func (wc *WebController) AfterAction(result interface{}, err error) error {
+	return err
 }
 
+// Invoked before the response is sent, even if BeforeAction terminates the request or the action panics.
 func (wc *WebController) BeforeResponse() {
 	wc.saveSession()
 }
 
+// Invoked at the end of the request, even if BeforeAction terminates the request or the action panics.
+func (wc *WebController) Finally() {
+}
+
 func (wc *WebController) Actions() WebActionRoutes {
 	return map[string]WebActionRoute{}
 }
@@ -97,6 +117,12 @@ func (wc *WebController) RenderData(data string, context ...interface{}) {
 
 // @param name the view file name.
 func (wc *WebController) RenderFile(name string, context ...interface{}) {
+	// Render the error instead of the view if the request's context was done, such as the deadline exceeded.
+	if err := wc.Context.Ctx().Err(); err != nil {
+		wc.Context.Error(contextError(err))
+		return
+	}
+
 	wc.Context.Response.SetHtmlHeader()
 
 	file := wc.getViewFile(name)
@@ -119,7 +145,8 @@ func (wc *WebController) RenderPartialFile(name string, context ...interface{})
 }
 
 // the v will be responsed directly if type of v is string.
-func (wc *WebController) RenderJson(v interface{}) {
+// The error will be returned if v cannot be marshaled.
+func (wc *WebController) RenderJson(v interface{}) error {
 	wc.Context.Response.SetJsonHeader()
 
 	if value, ok := v.(string); ok {
@@ -127,15 +154,17 @@ func (wc *WebController) RenderJson(v interface{}) {
 	} else {
 		json, err := json.Marshal(v)
 		if err != nil {
-			// wc.Response.InternalServerError(err.Error())
-			return
+			return err
 		}
 		wc.Context.Response.body += string(json)
 	}
+
+	return nil
 }
 
 // the v will be responsed directly if type of v is string.
-func (wc *WebController) RenderJsonp(v interface{}, callback string) {
+// The error will be returned if v cannot be marshaled.
+func (wc *WebController) RenderJsonp(v interface{}, callback string) error {
 	wc.Context.Response.SetJsonpHeader()
 
 	if value, ok := v.(string); ok {
@@ -144,11 +173,12 @@ func (wc *WebController) RenderJsonp(v interface{}, callback string) {
 
 		json, err := json.Marshal(v)
 		if err != nil {
-			// wc.Response.InternalServerError(err.Error())
-			return
+			return err
 		}
 		wc.Context.Response.body += callback + "(" + string(json) + ")"
 	}
+
+	return nil
 }
 
 func (wc *WebController) RenderText(text string) {
@@ -157,7 +187,8 @@ func (wc *WebController) RenderText(text string) {
 }
 
 // the v will be responsed directly if type of v is string.
-func (wc *WebController) RenderXml(v interface{}, header string) {
+// The error will be returned if v cannot be marshaled.
+func (wc *WebController) RenderXml(v interface{}, header string) error {
 	wc.Context.Response.SetXmlHeader()
 
 	if value, ok := v.(string); ok {
@@ -165,8 +196,7 @@ func (wc *WebController) RenderXml(v interface{}, header string) {
 	} else {
 		byteXML, err := xml.MarshalIndent(v, "", `   `)
 		if err != nil {
-			//wc.Response.InternalServerError(err.Error())
-			return
+			return err
 		}
 
 		if len(header) == 0 {
@@ -175,6 +205,8 @@ func (wc *WebController) RenderXml(v interface{}, header string) {
 
 		wc.Context.Response.body = header + string(byteXML)
 	}
+
+	return nil
 }
 
 func (wc *WebController) getViewFile(name string) string {
@@ -205,3 +237,9 @@ func (wc *WebController) Layout() (bool, string) {
 func (wc *WebController) SkipMiddlewares() map[string]SkipMiddlewares {
 	return map[string]SkipMiddlewares{}
 }
+
+// Get the additional middlewares of actions, the key is the action's method name, such as "ActionIndex".
+// They run after the application's middlewares.
+func (wc *WebController) Middlewares() map[string][]Middleware {
+	return map[string][]Middleware{}
+}
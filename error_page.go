@@ -0,0 +1,222 @@
+package clevergo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/hoisie/mustache"
+	"html"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrorOptions specifies how the default error handler renders the error pages.
+type ErrorOptions struct {
+	// The directory of error templates, such as "/app/views".
+	// If it is empty, the views directory of current controller will be used.
+	// The templates are looked up as "errors/{status}.html" and then "errors/error.html".
+	ViewsPath string
+
+	// Whether to render the error templates in current controller's layout.
+	Layout bool
+}
+
+func NewErrorOptions() ErrorOptions {
+	return ErrorOptions{
+		ViewsPath: "",
+		Layout:    false,
+	}
+}
+
+// The error body of JSON and XML.
+type errorBody struct {
+	XMLName xml.Name    `json:"-" xml:"error"`
+	Status  int         `json:"status" xml:"status"`
+	Code    string      `json:"code,omitempty" xml:"code,omitempty"`
+	Message string      `json:"message" xml:"message"`
+	Details interface{} `json:"details,omitempty" xml:"-"`
+}
+
+// The default error handler renders the error according to the request's Accept header,
+// AJAX requests and the requests those accept JSON get a JSON body, the requests those accept XML get an XML body,
+// otherwise an HTML page rendered by the error template, or the built-in page if there is no template.
+// The error's cause and stack will be displayed on the built-in page in development mode.
+func DefaultErrorHandler(ctx *Context, err *HTTPError) {
+	ctx.Response.SetStatus(err.Status)
+
+	if ctx.Request.IsAjax() {
+		renderJsonError(ctx, err)
+		return
+	}
+
+	switch ctx.Request.Accepts("text/html", "application/json", "application/xml", "text/xml") {
+	case "application/json":
+		renderJsonError(ctx, err)
+	case "application/xml", "text/xml":
+		renderXmlError(ctx, err)
+	default:
+		renderHtmlError(ctx, err)
+	}
+}
+
+// Returns the error's message which can be exposed to the client.
+// The message of internal server error is hidden in production mode.
+func errorMessage(err *HTTPError) string {
+	if err.Status >= http.StatusInternalServerError && Configuration.mode != ModeDev {
+		return http.StatusText(err.Status)
+	}
+	return err.Message
+}
+
+func newErrorBody(err *HTTPError) *errorBody {
+	body := &errorBody{
+		Status:  err.Status,
+		Code:    err.Code,
+		Message: errorMessage(err),
+	}
+	if err.Status < http.StatusInternalServerError || Configuration.mode == ModeDev {
+		body.Details = err.Details
+	}
+	return body
+}
+
+func renderJsonError(ctx *Context, err *HTTPError) {
+	body := newErrorBody(err)
+	data, e := json.Marshal(body)
+	if e != nil {
+		// The details cannot be marshaled.
+		body.Details = nil
+		data, _ = json.Marshal(body)
+	}
+
+	ctx.Response.SetJsonHeader()
+	ctx.Response.body = string(data)
+}
+
+func renderXmlError(ctx *Context, err *HTTPError) {
+	data, _ := xml.Marshal(newErrorBody(err))
+
+	ctx.Response.SetXmlHeader()
+	ctx.Response.body = xml.Header + string(data)
+}
+
+func renderHtmlError(ctx *Context, err *HTTPError) {
+	ctx.Response.SetHtmlHeader()
+
+	if file, layout := errorTemplate(ctx, err.Status); len(file) > 0 {
+		data := errorTemplateData(err)
+		if len(layout) > 0 {
+			ctx.Response.body = mustache.RenderFileInLayout(file, layout, data)
+		} else {
+			ctx.Response.body = mustache.RenderFile(file, data)
+		}
+		return
+	}
+
+	title := http.StatusText(err.Status)
+	body := fmt.Sprintf("<h1>%d %s</h1>", err.Status, title)
+
+	if message := errorMessage(err); message != title {
+		body += fmt.Sprintf(`<hr><div class="info">%s</div>`, html.EscapeString(message))
+	}
+
+	// Render the development error page for panics.
+	if Configuration.mode == ModeDev && err.pcs != nil {
+		ctx.Response.body = renderDevErrorPage(ctx, err)
+		return
+	}
+
+	if Configuration.mode == ModeDev {
+		if err.Cause != nil {
+			body += fmt.Sprintf(`<hr><div class="info">%s</div>`, html.EscapeString(err.Cause.Error()))
+		}
+		if err.stack != nil {
+			body += `<br><hr><h2>STACK INFO:</h2><hr><div class="stack">`
+			stack := html.EscapeString(string(err.stack))
+			stack = strings.Replace(stack, "\n", `<hr>`, -1)
+			stack = strings.Replace(stack, "\t", `&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;`, -1)
+			body += stack + "</div>"
+		}
+	}
+
+	ctx.Response.body = mustache.Render(errorPageTemplate, map[string]string{"title": title, "body": body})
+}
+
+// Returns the data of error template, the message and details are hidden as the JSON and XML bodies.
+func errorTemplateData(err *HTTPError) map[string]interface{} {
+	body := newErrorBody(err)
+	return map[string]interface{}{
+		"status":  body.Status,
+		"title":   http.StatusText(body.Status),
+		"code":    body.Code,
+		"message": body.Message,
+		"details": body.Details,
+	}
+}
+
+// Look up the error template of the status, and returns the template file and the layout file.
+// The template file is empty if there is no template.
+func errorTemplate(ctx *Context, status int) (string, string) {
+	var options ErrorOptions
+	if ctx.app != nil {
+		options = ctx.app.errorOptions
+	}
+
+	var controller *ControllerInfo
+	if ctx.action != nil {
+		controller = ctx.action.Controller()
+	}
+
+	viewsPath := options.ViewsPath
+	if len(viewsPath) == 0 {
+		if controller == nil || len(controller.viewsPath) == 0 {
+			return "", ""
+		}
+		viewsPath = path.Dir(controller.viewsPath)
+	}
+
+	layout := ""
+	if options.Layout && controller != nil {
+		layout = controller.layout
+	}
+
+	names := []string{strconv.Itoa(status), "error"}
+	for i := 0; i < len(names); i++ {
+		file := path.Join(viewsPath, "errors", names[i]+Configuration.viewSuffix)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, layout
+		}
+	}
+
+	return "", ""
+}
+
+const errorPageTemplate = `
+<html>
+<head>
+    <title>{{title}}</title>
+    <style>
+        hr {
+            border: 1px dotted;
+            color: rgba(3, 169, 244, 0.12);
+            clear: both;
+        }
+
+        .info {
+            color: red;
+            font-weight: bold;
+        }
+
+        .stack {
+            margin: 20px 30px;
+        }
+    </style>
+</head>
+<body>
+{{{body}}}
+</body>
+</html>
+`
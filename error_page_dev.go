@@ -0,0 +1,273 @@
+package clevergo
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/hoisie/mustache"
+	"net/http"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// The number of source lines displayed before and after the frame's line.
+const devSourceLines = 5
+
+// The packages whose frames are not treated as application's frames, they are matched exactly,
+// so that the application's packages under the same organization are not hidden.
+var devFrameworkPackages = map[string]bool{
+	reflect.TypeOf(Context{}).PkgPath():                 true,
+	reflect.TypeOf(Context{}).PkgPath() + "/middleware": true,
+	"github.com/clevergo/cache":                         true,
+	"github.com/clevergo/jwt":                           true,
+	"github.com/clevergo/log":                           true,
+	"github.com/clevergo/session":                       true,
+	"github.com/julienschmidt/httprouter":               true,
+	"github.com/hoisie/mustache":                        true,
+	"net/http":                                          true,
+}
+
+// Render the development error page of the panic, which includes the panic value,
+// the stack with source snippets of application's frames, the request's details and the curl command.
+// It must not be rendered in production mode.
+func renderDevErrorPage(ctx *Context, err *HTTPError) string {
+	message := err.Message
+	if err.Cause != nil {
+		message = err.Cause.Error()
+	}
+
+	return mustache.Render(devErrorPageTemplate, map[string]interface{}{
+		"status":  err.Status,
+		"title":   http.StatusText(err.Status),
+		"message": message,
+		"frames":  devStackFrames(err.pcs),
+		"method":  ctx.Request.Method,
+		"url":     ctx.Request.URL.String(),
+		"headers": devHeaders(ctx),
+		"params":  devParams(ctx),
+		"form":    devForm(ctx),
+		"session": devSession(ctx),
+		"curl":    devCurlCommand(ctx),
+	})
+}
+
+func isAppFrame(function, file string) bool {
+	if strings.HasPrefix(file, runtime.GOROOT()) {
+		return false
+	}
+	return !devFrameworkPackages[funcPackage(function)]
+}
+
+// Returns the package path of the function's name,
+// such as "github.com/clevergo/clevergo" of "github.com/clevergo/clevergo.(*Context).Flush".
+func funcPackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+func devStackFrames(pcs []uintptr) []map[string]interface{} {
+	frames := make([]map[string]interface{}, 0, len(pcs))
+	callersFrames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callersFrames.Next()
+		// Skip the runtime's frames, such as runtime.gopanic.
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			app := isAppFrame(frame.Function, frame.File)
+			f := map[string]interface{}{
+				"function": frame.Function,
+				"file":     frame.File,
+				"line":     frame.Line,
+				"app":      app,
+			}
+			if app {
+				f["source"] = devSource(frame.File, frame.Line)
+			}
+			frames = append(frames, f)
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// Returns the source lines around the line of the file.
+func devSource(file string, line int) []map[string]interface{} {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	lines := make([]map[string]interface{}, 0, 2*devSourceLines+1)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if n < line-devSourceLines {
+			continue
+		}
+		if n > line+devSourceLines {
+			break
+		}
+		lines = append(lines, map[string]interface{}{
+			"number":  n,
+			"code":    scanner.Text(),
+			"current": n == line,
+		})
+	}
+	return lines
+}
+
+// Convert the values to a sorted list of name and value.
+func devValues(values map[string][]string) []map[string]string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]map[string]string, 0, len(names))
+	for i := 0; i < len(names); i++ {
+		list = append(list, map[string]string{
+			"name":  names[i],
+			"value": strings.Join(values[names[i]], ", "),
+		})
+	}
+	return list
+}
+
+func devHeaders(ctx *Context) []map[string]string {
+	return devValues(ctx.Request.Header)
+}
+
+func devParams(ctx *Context) []map[string]string {
+	values := make(map[string][]string, len(ctx.Params.Params))
+	for i := 0; i < len(ctx.Params.Params); i++ {
+		values[ctx.Params.Params[i].Key] = append(values[ctx.Params.Params[i].Key], ctx.Params.Params[i].Value)
+	}
+	return devValues(values)
+}
+
+// Returns the form values, the form will not be parsed if it has not been parsed.
+func devForm(ctx *Context) []map[string]string {
+	return devValues(ctx.Request.Form)
+}
+
+func devSession(ctx *Context) []map[string]string {
+	if ctx.Session == nil {
+		return nil
+	}
+	values := make(map[string][]string, len(ctx.Session.Values))
+	for k, v := range ctx.Session.Values {
+		values[fmt.Sprint(k)] = []string{fmt.Sprint(v)}
+	}
+	return devValues(values)
+}
+
+// Returns the curl command which reproduces the request, the body is included only if it is a parsed form.
+func devCurlCommand(ctx *Context) string {
+	r := ctx.Request
+	command := "curl -X " + r.Method + " " + shellQuote(r.Scheme()+"://"+r.ForwardedHost()+r.URL.RequestURI())
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			command += " -H " + shellQuote(name+": "+value)
+		}
+	}
+
+	if r.PostForm != nil && len(r.PostForm) > 0 && r.MultipartForm == nil {
+		command += " --data-raw " + shellQuote(r.PostForm.Encode())
+	}
+
+	return command
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+const devErrorPageTemplate = `
+<html>
+<head>
+    <title>{{status}} {{title}}</title>
+    <style>
+        body { font-family: sans-serif; margin: 0; color: #333; }
+        header { background: #e53935; color: #fff; padding: 20px 30px; }
+        header h1 { margin: 0 0 10px; font-size: 20px; }
+        header pre { margin: 0; white-space: pre-wrap; font-size: 16px; }
+        section { padding: 10px 30px; }
+        .frame { border-bottom: 1px solid #eee; padding: 8px 0; }
+        .frame .function { font-weight: bold; }
+        .frame .file { color: #888; font-size: 12px; }
+        .frame.app .function { color: #e53935; }
+        .frame.framework { opacity: 0.6; }
+        .source { background: #f7f7f7; font-family: monospace; font-size: 12px; margin: 6px 0; padding: 6px 0; }
+        .source div { white-space: pre; padding: 0 10px; }
+        .source .current { background: #ffcdd2; }
+        .source .number { color: #999; display: inline-block; width: 50px; }
+        table { border-collapse: collapse; width: 100%; font-size: 13px; }
+        td { border-bottom: 1px solid #eee; padding: 4px 8px; vertical-align: top; word-break: break-all; }
+        td.name { font-weight: bold; width: 25%; }
+        textarea { width: 100%; height: 80px; font-family: monospace; font-size: 12px; }
+    </style>
+</head>
+<body>
+<header>
+    <h1>{{status}} {{title}}</h1>
+    <pre>{{message}}</pre>
+</header>
+<section>
+    <h2>Stack</h2>
+    {{#frames}}
+    <div class="frame {{#app}}app{{/app}}{{^app}}framework{{/app}}">
+        <div class="function">{{function}}</div>
+        <div class="file">{{file}}:{{line}}</div>
+        {{#app}}
+        <div class="source">
+            {{#source}}<div class="{{#current}}current{{/current}}"><span class="number">{{number}}</span>{{code}}</div>{{/source}}
+        </div>
+        {{/app}}
+    </div>
+    {{/frames}}
+</section>
+<section>
+    <h2>Request</h2>
+    <table>
+        <tr><td class="name">Method</td><td>{{method}}</td></tr>
+        <tr><td class="name">URL</td><td>{{url}}</td></tr>
+    </table>
+    <h3>Headers</h3>
+    <table>{{#headers}}<tr><td class="name">{{name}}</td><td>{{value}}</td></tr>{{/headers}}</table>
+    <h3>Params</h3>
+    <table>{{#params}}<tr><td class="name">{{name}}</td><td>{{value}}</td></tr>{{/params}}</table>
+    <h3>Form</h3>
+    <table>{{#form}}<tr><td class="name">{{name}}</td><td>{{value}}</td></tr>{{/form}}</table>
+    <h3>Session</h3>
+    <table>{{#session}}<tr><td class="name">{{name}}</td><td>{{value}}</td></tr>{{/session}}</table>
+    <h3>cURL</h3>
+    <textarea id="curl" readonly>{{curl}}</textarea>
+    <button onclick="copyCurl()">Copy as cURL</button>
+</section>
+<script>
+    function copyCurl() {
+        var curl = document.getElementById('curl');
+        if (navigator.clipboard) {
+            navigator.clipboard.writeText(curl.value);
+        } else {
+            curl.select();
+            document.execCommand('copy');
+        }
+    }
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,63 @@
+package clevergo
+
+import (
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestIsAppFrame(t *testing.T) {
+	tests := []struct {
+		function string
+		file     string
+		expected bool
+	}{
+		{"github.com/clevergo/clevergo.(*Context).Flush", "/go/src/github.com/clevergo/clevergo/context.go", false},
+		{"github.com/clevergo/clevergo/middleware.(*JWTMiddleware).Handle.func1", "/go/src/github.com/clevergo/clevergo/middleware/jwt.go", false},
+		{"github.com/julienschmidt/httprouter.(*Router).ServeHTTP", "/go/src/github.com/julienschmidt/httprouter/router.go", false},
+		{"github.com/clevergo/shop/controllers.(*Home).ActionIndex", "/go/src/github.com/clevergo/shop/controllers/home.go", true},
+		{"github.com/clevergo/clevergo-demo.main", "/go/src/github.com/clevergo/clevergo-demo/main.go", true},
+		{"main.main", "/app/main.go", true},
+		{"net/http.HandlerFunc.ServeHTTP", runtime.GOROOT() + "/src/net/http/server.go", false},
+	}
+	for _, test := range tests {
+		if actual := isAppFrame(test.function, test.file); actual != test.expected {
+			t.Errorf("isAppFrame(%q) = %t, expected %t", test.function, actual, test.expected)
+		}
+	}
+}
+
+func TestDevStackFrames(t *testing.T) {
+	pcs := make([]uintptr, 16)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+
+	frames := devStackFrames(pcs)
+	if len(frames) == 0 || !strings.HasSuffix(frames[0]["function"].(string), "TestDevStackFrames") {
+		t.Fatalf("devStackFrames() = %v, expected the test's frame first", frames)
+	}
+	if frames[0]["app"] != false {
+		t.Errorf("the framework's frame should not be treated as application's")
+	}
+}
+
+func TestDevRequestDetails(t *testing.T) {
+	r := httptest.NewRequest("POST", "http://example.com/users/1?tab=posts", strings.NewReader("name=clever+go"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Note", "it's")
+	ctx := NewContext(nil, httptest.NewRecorder(), r, httprouter.Params{{Key: "id", Value: "1"}})
+	ctx.Request.ParseForm()
+
+	expected := `curl -X POST 'http://example.com/users/1?tab=posts' -H 'Content-Type: application/x-www-form-urlencoded' -H 'X-Note: it'\''s' --data-raw 'name=clever+go'`
+	if command := devCurlCommand(ctx); command != expected {
+		t.Errorf("devCurlCommand() = %s, expected %s", command, expected)
+	}
+	if params := devParams(ctx); len(params) != 1 || params[0]["name"] != "id" || params[0]["value"] != "1" {
+		t.Errorf("devParams() = %v", params)
+	}
+	if form := devForm(ctx); len(form) != 2 || form[0]["name"] != "name" || form[1]["name"] != "tab" {
+		t.Errorf("devForm() = %v", form)
+	}
+}
@@ -0,0 +1,73 @@
+package clevergo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDefaultErrorHandler(t *testing.T) {
+	defer func(mode int) {
+		Configuration.mode = mode
+	}(Configuration.mode)
+	Configuration.mode = ModePro
+
+	invalid := &HTTPError{Status: http.StatusBadRequest, Code: "invalid_input", Message: "The input is invalid.", Details: map[string]string{"name": "required"}}
+	internal := &HTTPError{Status: http.StatusInternalServerError, Message: "database is down", Details: "secret", Cause: errors.New("dial tcp")}
+
+	tests := []struct {
+		err         *HTTPError
+		accept      string
+		ajax        bool
+		contentType string
+		body        string
+	}{
+		{invalid, "application/json", false, "application/json", `{"status":400,"code":"invalid_input","message":"The input is invalid.","details":{"name":"required"}}`},
+		{invalid, "text/html", true, "application/json", `"code":"invalid_input"`},
+		{invalid, "application/xml", false, "application/xml", `<error><status>400</status><code>invalid_input</code><message>The input is invalid.</message></error>`},
+		{internal, "application/json", false, "application/json", `{"status":500,"message":"Internal Server Error"}`},
+		{internal, "text/html", false, "text/html", ""},
+	}
+	for i, test := range tests {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", test.accept)
+		if test.ajax {
+			r.Header.Set("X-Requested-With", "XMLHttpRequest")
+		}
+		ctx := NewContext(nil, rw, r, nil)
+		DefaultErrorHandler(ctx, test.err)
+		ctx.Flush()
+
+		if rw.Code != test.err.Status {
+			t.Errorf("%d. status = %d, expected %d", i, rw.Code, test.err.Status)
+		}
+		if contentType := rw.Header().Get("Content-Type"); !strings.HasPrefix(contentType, test.contentType) {
+			t.Errorf("%d. content type = %q, expected %s", i, contentType, test.contentType)
+		}
+		if !strings.Contains(rw.Body.String(), test.body) {
+			t.Errorf("%d. body = %s, expected %s", i, rw.Body.String(), test.body)
+		}
+		if strings.Contains(rw.Body.String(), "secret") || strings.Contains(rw.Body.String(), "database") {
+			t.Errorf("%d. body = %s, the internal error should be hidden", i, rw.Body.String())
+		}
+	}
+}
+
+func TestErrorTemplateData(t *testing.T) {
+	defer func(mode int) {
+		Configuration.mode = mode
+	}(Configuration.mode)
+
+	err := &HTTPError{Status: http.StatusInternalServerError, Message: "database is down", Details: "secret"}
+	Configuration.mode = ModePro
+	if data := errorTemplateData(err); data["details"] != nil || data["message"] != "Internal Server Error" {
+		t.Errorf("errorTemplateData() = %v in production mode, expected the details and message hidden", data)
+	}
+	Configuration.mode = ModeDev
+	if data := errorTemplateData(err); data["details"] != "secret" || data["message"] != "database is down" {
+		t.Errorf("errorTemplateData() = %v in development mode, expected the details and message", data)
+	}
+}
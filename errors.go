@@ -0,0 +1,99 @@
+package clevergo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// ErrAbort can be returned by BeforeAction to terminate the request without an error response,
+// the response that has been set will be sent as usual.
+var ErrAbort = errors.New("The action was aborted.")
+
+// HTTPError is an error with HTTP status, it is rendered by the application's error handler.
+type HTTPError struct {
+	Status  int         // HTTP status code.
+	Code    string      // Application specific error code, optional.
+	Message string      // Human-readable message.
+	Details interface{} // Additional details, such as the invalid fields.
+	Cause   error       // The underlying error, it is not exposed to client in production mode.
+	stack   []byte      // The stack of panic.
+	pcs     []uintptr   // The program counters of panic, see also runtime.Callers.
+}
+
+// Create an HTTPError, the message will be set as status text if it is empty.
+func NewHTTPError(status int, message string) *HTTPError {
+	if len(message) == 0 {
+		message = http.StatusText(status)
+	}
+	return &HTTPError{
+		Status:  status,
+		Message: message,
+	}
+}
+
+// Wrap the error as an HTTPError with the status, the error's message will be used as message.
+func WrapError(status int, err error) *HTTPError {
+	return &HTTPError{
+		Status:  status,
+		Message: err.Error(),
+		Cause:   err,
+	}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%d %s: %s", e.Status, e.Message, e.Cause.Error())
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// Returns the stack of panic, it is nil if the error was not reached by panic.
+func (e *HTTPError) Stack() []byte {
+	return e.stack
+}
+
+// The error which can be converted to HTTPError, such as ValidationErrors.
+type httpErrorer interface {
+	HTTPError() *HTTPError
+}
+
+// Convert the error to HTTPError, the error which is neither an HTTPError nor convertible
+// is treated as internal server error.
+func ToHTTPError(err error) *HTTPError {
+	switch e := err.(type) {
+	case *HTTPError:
+		return e
+	case httpErrorer:
+		return e.HTTPError()
+	}
+	return &HTTPError{
+		Status:  http.StatusInternalServerError,
+		Message: http.StatusText(http.StatusInternalServerError),
+		Cause:   err,
+	}
+}
+
+// Convert the recovered value of panic to HTTPError.
+func panicError(v interface{}) *HTTPError {
+	var e *HTTPError
+	switch value := v.(type) {
+	case *HTTPError:
+		e = value
+	case error:
+		e = ToHTTPError(value)
+	default:
+		e = ToHTTPError(fmt.Errorf("%v", value))
+	}
+	// Attach the stack to a copy, since the error may be shared by requests, such as a package-level error.
+	copied := *e
+	e = &copied
+	if e.stack == nil {
+		e.stack = debug.Stack()
+		e.pcs = make([]uintptr, 64)
+		e.pcs = e.pcs[:runtime.Callers(2, e.pcs)]
+	}
+	return e
+}
@@ -4,12 +4,7 @@
 package clevergo
 
 import (
-	"fmt"
-	"github.com/hoisie/mustache"
 	"net/http"
-	"runtime"
-	"runtime/debug"
-	"strings"
 )
 
 type Handler interface {
@@ -29,66 +24,37 @@ type RouteHandler struct {
 }
 
 type NotFoundHandler struct {
+	app *Application
 }
 
 func (h *NotFoundHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	errorHandler(w, r, 404, http.StatusText(404), 0)
+	serveError(h.app, w, r, NewHTTPError(http.StatusNotFound, ""), h.app != nil && h.app.problemOptions.Routing)
 }
 
 type MethodNotAllowedHandler struct {
+	app *Application
 }
 
 func (h *MethodNotAllowedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	errorHandler(w, r, 405, http.StatusText(405), 0)
+	serveError(h.app, w, r, NewHTTPError(http.StatusMethodNotAllowed, ""), h.app != nil && h.app.problemOptions.Routing)
 }
 
+// ErrorHandler renders the error into the context's response.
+type ErrorHandler func(ctx *Context, err *HTTPError)
+
+// Render the panic by the default error handler.
 func PanicHandler(w http.ResponseWriter, r *http.Request, v interface{}) {
-	errorHandler(w, r, 500, v, 5)
+	serveError(nil, w, r, panicError(v), false)
 }
 
-type ErrorHandler func(http.ResponseWriter, *http.Request, int, interface{}, int)
-
-func errorHandler(w http.ResponseWriter, r *http.Request, status int, v interface{}, callDepth int) {
-	w.WriteHeader(status)
-
-	title := http.StatusText(status)
-	body := fmt.Sprintf("<h1>%d %s</h1>", status, http.StatusText(status))
-
-	if Configuration.mode == ModeDev {
-		if _, file, line, ok := runtime.Caller(callDepth); ok {
-			body += fmt.Sprintf(`<hr><div class="info">%s: %d</div><br><div class="info">%s</div>`, file, line, v)
-		}
-		body += `<br><hr><h2>STACK INFO:</h2><hr><div class="stack">`
-		stack := string(debug.Stack())
-		stack = strings.Replace(stack, "\n", `<hr>`, -1)
-		stack = strings.Replace(stack, "\t", `&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;`, -1)
-		body += stack + "</div>"
-	}
-	html := mustache.Render(`
-<html>
-<head>
-    <title>{{title}}</title>
-    <style>
-        hr {
-            border: 1px dotted;
-            color: rgba(3, 169, 244, 0.12);
-            clear: both;
-        }
+// Render the error by the application's error handler and send the response.
+// The error will be rendered as problem details if problem is true.
+func serveError(app *Application, w http.ResponseWriter, r *http.Request, err *HTTPError, problem bool) {
+	ctx := acquireContext(app, w, r, nil)
+	ctx.problem = problem
 
-        .info {
-            color: red;
-            font-weight: bold;
-        }
+	defer releaseContext(ctx)
+	defer ctx.Flush()
 
-        .stack {
-            margin: 20px 30px;
-        }
-    </style>
-</head>
-<body>
-{{{body}}}
-</body>
-</html>
-`, map[string]string{"title": title, "body": body})
-	fmt.Fprint(w, html)
+	ctx.Error(err)
 }
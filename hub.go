@@ -0,0 +1,338 @@
+package clevergo
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// The size of subscription's buffer, the messages will be dropped if the subscriber is too slow.
+const subscriptionBufferSize = 64
+
+var errSubscriptionClosed = errors.New("the subscription is closed")
+
+// Message is published to a room of hub.
+type Message struct {
+	Room  string          `json:"room"`
+	Event string          `json:"event,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Broker delivers the published messages to the hubs, the hubs of multiple server instances can share
+// the messages by a distributed broker, such as RedisBroker.
+type Broker interface {
+	// Start delivering the messages of the subscribed rooms by deliver.
+	Start(deliver func(room string, message []byte)) error
+	Publish(room string, message []byte) error
+	Subscribe(room string) error
+	Unsubscribe(room string) error
+	Close() error
+}
+
+// Hub fans out the messages of rooms to the subscriptions, the SSE and WebSocket clients can join the rooms
+// by subscriptions. It is usually registered as a service, for example:
+//
+//	hub, _ := clevergo.NewHub(clevergo.NewRedisBroker(cache))
+//	app.RegisterService(hub)
+//
+//	func (c *Notifications) ActionIndex(hub *clevergo.Hub) error {
+//		subscription, err := hub.Subscribe("user:" + c.Context.Params.String("id"))
+//		if err != nil {
+//			return err
+//		}
+//		defer subscription.Close()
+//		return subscription.ServeSSE(c.Context.SSE())
+//	}
+type Hub struct {
+	broker Broker
+	joinMu sync.Mutex // serializes joining and leaving, so that the broker's I/O does not block delivering.
+	mu     sync.RWMutex
+	rooms  map[string]map[*Subscription]bool
+}
+
+// Create a hub with the broker, the in-process broker is used if broker is nil.
+func NewHub(broker Broker) (*Hub, error) {
+	if broker == nil {
+		broker = NewMemoryBroker()
+	}
+	h := &Hub{
+		broker: broker,
+		rooms:  make(map[string]map[*Subscription]bool),
+	}
+	if err := broker.Start(h.deliver); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Publish an event to the room, the data is encoded as JSON unless it is json.RawMessage or []byte.
+func (h *Hub) Publish(room, event string, data interface{}) error {
+	var raw json.RawMessage
+	switch value := data.(type) {
+	case json.RawMessage:
+		raw = value
+	case []byte:
+		raw = value
+	default:
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+
+	message, err := json.Marshal(&Message{Room: room, Event: event, Data: raw})
+	if err != nil {
+		return err
+	}
+	return h.broker.Publish(room, message)
+}
+
+// Create a subscription which joins the rooms, it must be closed after using.
+// The error of joining the rooms is returned, such as the broker failed to subscribe.
+func (h *Hub) Subscribe(rooms ...string) (*Subscription, error) {
+	s := &Subscription{
+		hub:   h,
+		C:     make(chan *Message, subscriptionBufferSize),
+		rooms: make(map[string]bool),
+	}
+	if err := s.Join(rooms...); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close the broker.
+func (h *Hub) Close() error {
+	return h.broker.Close()
+}
+
+// Deliver the message to the subscriptions of the room.
+func (h *Hub) deliver(room string, data []byte) {
+	message := &Message{}
+	if err := json.Unmarshal(data, message); err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.rooms[room] {
+		s.send(message)
+	}
+}
+
+// Join the room, the broker subscribes the room if it is new. The room is added before subscribing,
+// so that the messages are delivered as soon as the broker subscribed it, and it is removed if failed.
+func (h *Hub) join(s *Subscription, room string) error {
+	h.joinMu.Lock()
+	defer h.joinMu.Unlock()
+
+	h.mu.Lock()
+	subscriptions, ok := h.rooms[room]
+	if ok {
+		subscriptions[s] = true
+		h.mu.Unlock()
+		return nil
+	}
+	h.rooms[room] = map[*Subscription]bool{s: true}
+	h.mu.Unlock()
+
+	if err := h.broker.Subscribe(room); err != nil {
+		h.mu.Lock()
+		delete(h.rooms, room)
+		h.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Leave the room, the broker unsubscribes the room if it has no subscriptions.
+func (h *Hub) leave(s *Subscription, room string) error {
+	h.joinMu.Lock()
+	defer h.joinMu.Unlock()
+
+	h.mu.Lock()
+	subscriptions, ok := h.rooms[room]
+	if !ok {
+		h.mu.Unlock()
+		return nil
+	}
+	delete(subscriptions, s)
+	empty := len(subscriptions) == 0
+	if empty {
+		delete(h.rooms, room)
+	}
+	h.mu.Unlock()
+
+	if empty {
+		return h.broker.Unsubscribe(room)
+	}
+	return nil
+}
+
+// Subscription receives the messages of the joined rooms by C.
+// The messages will be dropped if C is full, so that a slow client never blocks the others.
+type Subscription struct {
+	hub    *Hub
+	C      chan *Message
+	mu     sync.Mutex
+	rooms  map[string]bool
+	closed bool
+}
+
+// Join the rooms, the room is marked as joined only if the hub joined it successfully.
+func (s *Subscription) Join(rooms ...string) error {
+	for _, room := range rooms {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return errSubscriptionClosed
+		}
+		joined := s.rooms[room]
+		s.mu.Unlock()
+		if joined {
+			continue
+		}
+		if err := s.hub.join(s, room); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		closed := s.closed
+		if !closed {
+			s.rooms[room] = true
+		}
+		s.mu.Unlock()
+		if closed {
+			// The subscription was closed while joining.
+			s.hub.leave(s, room)
+			return errSubscriptionClosed
+		}
+	}
+	return nil
+}
+
+func (s *Subscription) Leave(rooms ...string) error {
+	for _, room := range rooms {
+		s.mu.Lock()
+		joined := s.rooms[room]
+		delete(s.rooms, room)
+		s.mu.Unlock()
+		if !joined {
+			continue
+		}
+		if err := s.hub.leave(s, room); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Leave all the rooms and close C.
+func (s *Subscription) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	rooms := make([]string, 0, len(s.rooms))
+	for room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.rooms = make(map[string]bool)
+	close(s.C)
+	s.mu.Unlock()
+
+	for _, room := range rooms {
+		s.hub.leave(s, room)
+	}
+}
+
+func (s *Subscription) send(message *Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.C <- message:
+	default:
+	}
+}
+
+// Forward the messages to the SSE client until the subscription is closed or the client disconnected,
+// the message's event is sent as the event's type.
+func (s *Subscription) ServeSSE(sse *SSE) error {
+	for {
+		select {
+		case message, ok := <-s.C:
+			if !ok {
+				return nil
+			}
+			if err := sse.Send(message.Event, "", []byte(message.Data)); err != nil {
+				return err
+			}
+		case <-sse.Done():
+			return nil
+		}
+	}
+}
+
+// Forward the messages as JSON to the WebSocket client until the subscription or the connection is closed.
+// The connection's messages should be read by another goroutine, so that the pings and close frames
+// are handled.
+func (s *Subscription) ServeWebSocket(ws *WebSocket) error {
+	for {
+		select {
+		case message, ok := <-s.C:
+			if !ok {
+				return nil
+			}
+			if err := ws.WriteJSON(message); err != nil {
+				return err
+			}
+		case <-ws.closed:
+			return nil
+		}
+	}
+}
+
+// MemoryBroker delivers the messages in process, it is only suitable for single server instance.
+type MemoryBroker struct {
+	mu      sync.RWMutex
+	deliver func(room string, message []byte)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Start(deliver func(room string, message []byte)) error {
+	b.mu.Lock()
+	b.deliver = deliver
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBroker) Publish(room string, message []byte) error {
+	b.mu.RLock()
+	deliver := b.deliver
+	b.mu.RUnlock()
+	if deliver != nil {
+		deliver(room, message)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(room string) error {
+	return nil
+}
+
+func (b *MemoryBroker) Unsubscribe(room string) error {
+	return nil
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
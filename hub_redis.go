@@ -0,0 +1,186 @@
+package clevergo
+
+import (
+	"fmt"
+	"github.com/clevergo/cache"
+	"github.com/clevergo/log"
+	"github.com/garyburd/redigo/redis"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisBroker delivers the messages by Redis's pub/sub, so that the hubs of multiple server instances
+// share the messages. Each room is a Redis channel prefixed by the Prefix.
+// It reconnects and resubscribes the rooms automatically if the connection is broken.
+type RedisBroker struct {
+	Prefix         string        // the prefix of channels.
+	HealthInterval time.Duration // the interval of pings which check the connection's health.
+	Logger         *log.Logger   // the logger of disconnections, nil means that they are not logged.
+
+	pool    *redis.Pool
+	deliver func(room string, message []byte)
+	mu      sync.Mutex
+	psc     *redis.PubSubConn // the current connection, nil if disconnected.
+	rooms   map[string]bool
+	done    chan struct{}
+	broken  bool // whether the disconnection has been logged, it is reset after reconnecting.
+}
+
+// Create a broker by the pool of the cache, see also Application's cache.
+func NewRedisBroker(c *cache.RedisCache) *RedisBroker {
+	return newRedisBroker(c.GetPool())
+}
+
+func newRedisBroker(pool *redis.Pool) *RedisBroker {
+	return &RedisBroker{
+		Prefix:         "clevergo:hub:",
+		HealthInterval: 30 * time.Second,
+		Logger:         nil,
+		pool:           pool,
+		rooms:          make(map[string]bool),
+		done:           make(chan struct{}),
+	}
+}
+
+func (b *RedisBroker) Start(deliver func(room string, message []byte)) error {
+	b.deliver = deliver
+	go b.run()
+	return nil
+}
+
+func (b *RedisBroker) Publish(room string, message []byte) error {
+	conn := b.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PUBLISH", b.Prefix+room, message)
+	return err
+}
+
+func (b *RedisBroker) Subscribe(room string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rooms[room] = true
+	if b.psc != nil {
+		return b.psc.Subscribe(b.Prefix + room)
+	}
+	return nil
+}
+
+func (b *RedisBroker) Unsubscribe(room string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.rooms, room)
+	if b.psc != nil {
+		return b.psc.Unsubscribe(b.Prefix + room)
+	}
+	return nil
+}
+
+// Stop receiving messages, all channels are unsubscribed and the connection is closed.
+func (b *RedisBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+	default:
+	}
+	close(b.done)
+	if b.psc != nil {
+		return b.psc.Unsubscribe()
+	}
+	return nil
+}
+
+// Receive messages until the broker is closed, reconnect after a second if the connection is broken.
+// Only the first failure is logged until the connection is recovered, so that the log is not flooded.
+func (b *RedisBroker) run() {
+	for {
+		if err := b.receive(); err != nil && !b.broken {
+			b.broken = true
+			if b.Logger != nil {
+				l := b.Logger.NewLog()
+				l.Warn(fmt.Sprintf("The redis broker is disconnected, it is reconnecting: %s.", err))
+				l.Flush()
+			}
+		}
+		select {
+		case <-b.done:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (b *RedisBroker) receive() error {
+	psc := &redis.PubSubConn{Conn: b.pool.Get()}
+	defer psc.Close()
+
+	// The control channel keeps the connection in the subscribed state even if there is no room.
+	b.mu.Lock()
+	select {
+	case <-b.done:
+		b.mu.Unlock()
+		return nil
+	default:
+	}
+	channels := []interface{}{b.Prefix}
+	for room := range b.rooms {
+		channels = append(channels, b.Prefix+room)
+	}
+	if err := psc.Subscribe(channels...); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	b.psc = psc
+	b.broken = false
+	b.mu.Unlock()
+
+	stop := make(chan struct{})
+	defer func() {
+		close(stop)
+		b.mu.Lock()
+		b.psc = nil
+		b.mu.Unlock()
+	}()
+	go b.ping(psc, stop)
+
+	for {
+		switch v := psc.ReceiveWithTimeout(2 * b.HealthInterval).(type) {
+		case redis.Message:
+			if v.Channel != b.Prefix {
+				b.deliver(strings.TrimPrefix(v.Channel, b.Prefix), v.Data)
+			}
+		case redis.Subscription:
+			if v.Count == 0 {
+				// All channels are unsubscribed by Close.
+				return nil
+			}
+		case error:
+			return v
+		}
+	}
+}
+
+// Ping periodically, so that the broken connection is detected by the receiving timeout.
+func (b *RedisBroker) ping(psc *redis.PubSubConn, stop chan struct{}) {
+	ticker := time.NewTicker(b.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.mu.Lock()
+			err := psc.Ping("")
+			b.mu.Unlock()
+			if err != nil {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package clevergo
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+func TestHub(t *testing.T) {
+	hub, err := NewHub(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hub.Close()
+
+	foo, _ := hub.Subscribe("foo")
+	all, err := hub.Subscribe("foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer all.Close()
+
+	hub.Publish("foo", "greeting", map[string]string{"text": "hello"})
+	hub.Publish("bar", "", "bar")
+	foo.Close()
+	hub.Publish("foo", "", "after closed")
+
+	if message := <-foo.C; message == nil || message.Event != "greeting" || string(message.Data) != `{"text":"hello"}` {
+		t.Errorf("message of foo = %+v", message)
+	}
+	if _, ok := <-foo.C; ok {
+		t.Error("the closed subscription receives messages")
+	}
+
+	expected := []string{`{"text":"hello"}`, `"bar"`, `"after closed"`}
+	for i := 0; i < len(expected); i++ {
+		if message := <-all.C; string(message.Data) != expected[i] {
+			t.Errorf("%d: message = %s, expected %s", i, message.Data, expected[i])
+		}
+	}
+
+	if len(hub.rooms["foo"]) != 1 {
+		t.Errorf("room foo has %d subscriptions, expected 1", len(hub.rooms["foo"]))
+	}
+	all.Close()
+	if len(hub.rooms) != 0 {
+		t.Errorf("the rooms are not released: %v", hub.rooms)
+	}
+}
+
+// failingBroker fails to subscribe the rooms.
+type failingBroker struct {
+	MemoryBroker
+}
+
+func (b *failingBroker) Subscribe(room string) error {
+	return errors.New("subscribe " + room + " failed")
+}
+
+func TestHubSubscribeError(t *testing.T) {
+	hub, _ := NewHub(&failingBroker{})
+	if s, err := hub.Subscribe("foo"); err == nil || s != nil {
+		t.Errorf("Subscribe() = %v, %v, expected the error of broker", s, err)
+	}
+	if len(hub.rooms) != 0 {
+		t.Errorf("the failed rooms are joined: %v", hub.rooms)
+	}
+}
+
+// blockingBroker blocks subscribing until unblock is closed.
+type blockingBroker struct {
+	MemoryBroker
+	subscribing chan struct{}
+	unblock     chan struct{}
+}
+
+func (b *blockingBroker) Subscribe(room string) error {
+	close(b.subscribing)
+	<-b.unblock
+	return nil
+}
+
+// The messages of the joined rooms are delivered while the broker is subscribing another room.
+func TestHubJoinDoesNotBlockDelivering(t *testing.T) {
+	broker := &blockingBroker{subscribing: make(chan struct{}), unblock: make(chan struct{})}
+	hub, _ := NewHub(broker)
+	foo := &Subscription{hub: hub, C: make(chan *Message, 1), rooms: make(map[string]bool)}
+	hub.rooms["foo"] = map[*Subscription]bool{foo: true}
+
+	joined := make(chan error, 1)
+	go func() {
+		s, err := hub.Subscribe("bar")
+		if err == nil {
+			s.Close()
+		}
+		joined <- err
+	}()
+	<-broker.subscribing
+
+	hub.Publish("foo", "", "hello")
+	select {
+	case message := <-foo.C:
+		if string(message.Data) != `"hello"` {
+			t.Errorf("message = %s, expected \"hello\"", message.Data)
+		}
+	case <-time.After(time.Second):
+		t.Error("delivering is blocked by subscribing")
+	}
+
+	close(broker.unblock)
+	if err := <-joined; err != nil {
+		t.Errorf("Subscribe() returns error: %s", err)
+	}
+}
+
+func TestSubscriptionServeSSE(t *testing.T) {
+	hub, _ := NewHub(nil)
+	s, _ := hub.Subscribe("foo")
+	w := httptest.NewRecorder()
+	ctx := acquireContext(nil, w, httptest.NewRequest("GET", "/", nil), nil)
+	defer releaseContext(ctx)
+
+	hub.Publish("foo", "greeting", "hello")
+	s.Close()
+	if err := s.ServeSSE(ctx.SSE()); err != nil {
+		t.Errorf("ServeSSE() returns error: %s", err)
+	}
+	if expected := "event: greeting\ndata: \"hello\"\n\n"; w.Body.String() != expected {
+		t.Errorf("body = %q, expected %q", w.Body.String(), expected)
+	}
+}
+
+func TestSubscriptionServeWebSocket(t *testing.T) {
+	hub, _ := NewHub(nil)
+	subscribed := make(chan struct{})
+	options := NewWebSocketOptions()
+	options.Subprotocols = []string{"chat"}
+	app := NewApplication()
+	app.AddWebSocket("/ws", func(ws *WebSocket) {
+		s, err := hub.Subscribe("foo")
+		if err != nil {
+			return
+		}
+		defer s.Close()
+		close(subscribed)
+		go func() {
+			for {
+				if _, _, err := ws.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+		s.ServeWebSocket(ws)
+	}, options)
+	app.Run()
+	server := httptest.NewServer(app)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	conn, reader, _ := dialWebSocket(t, addr, "http://"+addr)
+	defer conn.Close()
+	<-subscribed
+	hub.Publish("foo", "greeting", "hello")
+
+	expected := `{"room":"foo","event":"greeting","data":"hello"}`
+	if opcode, payload, err := readServerFrame(reader); err != nil || opcode != TextMessage || string(payload) != expected {
+		t.Errorf("message = %d %q %v, expected %s", opcode, payload, err, expected)
+	}
+}
+
+func TestRedisBroker(t *testing.T) {
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", "127.0.0.1:6379")
+		},
+	}
+	defer pool.Close()
+	conn := pool.Get()
+	_, err := conn.Do("PING")
+	conn.Close()
+	if err != nil {
+		t.Skipf("redis is unavailable: %s", err)
+	}
+
+	broker := newRedisBroker(pool)
+	broker.Prefix = "clevergo:test:"
+	hub, err := NewHub(broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hub.Close()
+	s, err := hub.Subscribe("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// The messages are lost until the broker subscribed the room, so that publish until one is received.
+	timeout := time.After(5 * time.Second)
+	for {
+		hub.Publish("foo", "greeting", "hello")
+		select {
+		case message := <-s.C:
+			if message.Event != "greeting" || string(message.Data) != `"hello"` {
+				t.Errorf("message = %+v", message)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("no message is received from redis")
+		}
+	}
+}
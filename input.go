@@ -0,0 +1,272 @@
+package clevergo
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input provides the typed accessors of the request's values, such as query, form, header and cookie.
+// The missing values are treated as the default values, and the malformed values are recorded and
+// the default values are returned, all the errors can be retrieved by Err at once, for example:
+//
+//	query := ctx.Request.QueryInput()
+//	page := query.Int("page", 1)
+//	since := query.Time("since", time.RFC3339)
+//	if err := query.Err(); err != nil {
+//		return err // bad request with the errors of page and since.
+//	}
+type Input struct {
+	source string // the source of values, such as "query".
+	lookup func(name string) []string
+	list   func(name string) []string // the lookup of Strings, nil means lookup.
+	err    error                      // the error of parsing the source, such as malformed form.
+	errs   InputErrors
+}
+
+func newInput(source string, lookup func(name string) []string) *Input {
+	return &Input{
+		source: source,
+		lookup: lookup,
+	}
+}
+
+// Returns the query's input.
+func (r *Request) QueryInput() *Input {
+	query := r.URL.Query()
+	return newInput("query", func(name string) []string {
+		return query[name]
+	})
+}
+
+// Returns the form's input, including both the URL query and the POST form.
+// The form parsing error is recorded, see also Input.Err.
+func (r *Request) FormInput() *Input {
+	input := newInput("form", func(name string) []string {
+		if r.Form == nil {
+			return nil
+		}
+		return r.Form[name]
+	})
+	input.err = r.parseForm()
+	return input
+}
+
+// Returns the header's input. The single value is the first header which is not split by commas,
+// since some values contain commas, such as HTTP-date, but Strings splits the comma separated values.
+func (r *Request) HeaderInput() *Input {
+	input := newInput("header", func(name string) []string {
+		return r.Header[http.CanonicalHeaderKey(name)]
+	})
+	input.list = func(name string) []string {
+		return headerValues(r.Header, name)
+	}
+	return input
+}
+
+// Returns the cookie's input.
+func (r *Request) CookieInput() *Input {
+	return newInput("cookie", func(name string) []string {
+		values := make([]string, 0)
+		for _, cookie := range r.Cookies() {
+			if cookie.Name == name {
+				values = append(values, cookie.Value)
+			}
+		}
+		return values
+	})
+}
+
+// Returns the errors of malformed values, nil will be returned if there is no error.
+// The error of parsing the source is returned in preference, such as the form is malformed or too large.
+func (in *Input) Err() error {
+	if in.err != nil {
+		return in.err
+	}
+	if len(in.errs) == 0 {
+		return nil
+	}
+	return in.errs
+}
+
+// Returns a boolean indicating whether the value exists.
+func (in *Input) Has(name string) bool {
+	return len(in.lookup(name)) > 0
+}
+
+// Returns the first value by name, def will be returned if the value does not exist or is empty.
+func (in *Input) String(name string, def ...string) string {
+	if value, ok := in.value(name); ok {
+		return value
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return ""
+}
+
+// Returns all values by name, def will be returned if the value does not exist.
+func (in *Input) Strings(name string, def ...string) []string {
+	lookup := in.lookup
+	if in.list != nil {
+		lookup = in.list
+	}
+	if values := lookup(name); len(values) > 0 {
+		return values
+	}
+	return def
+}
+
+func (in *Input) Int(name string, def ...int) int {
+	var d int
+	if len(def) > 0 {
+		d = def[0]
+	}
+	value, ok := in.value(name)
+	if !ok {
+		return d
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		in.fail(name, "int", "%s must be an integer.")
+		return d
+	}
+	return i
+}
+
+func (in *Input) Int64(name string, def ...int64) int64 {
+	var d int64
+	if len(def) > 0 {
+		d = def[0]
+	}
+	value, ok := in.value(name)
+	if !ok {
+		return d
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		in.fail(name, "int", "%s must be an integer.")
+		return d
+	}
+	return i
+}
+
+func (in *Input) Float64(name string, def ...float64) float64 {
+	var d float64
+	if len(def) > 0 {
+		d = def[0]
+	}
+	value, ok := in.value(name)
+	if !ok {
+		return d
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		in.fail(name, "float", "%s must be a number.")
+		return d
+	}
+	return f
+}
+
+// Returns the boolean value by name, see also ParseBool.
+func (in *Input) Bool(name string, def ...bool) bool {
+	var d bool
+	if len(def) > 0 {
+		d = def[0]
+	}
+	value, ok := in.value(name)
+	if !ok {
+		return d
+	}
+	b, err := ParseBool(value)
+	if err != nil {
+		in.fail(name, "bool", "%s must be a boolean.")
+		return d
+	}
+	return b
+}
+
+// Returns the duration value by name, such as "300ms" and "1h30m", see also time.ParseDuration.
+func (in *Input) Duration(name string, def ...time.Duration) time.Duration {
+	var d time.Duration
+	if len(def) > 0 {
+		d = def[0]
+	}
+	value, ok := in.value(name)
+	if !ok {
+		return d
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		in.fail(name, "duration", "%s must be a duration.")
+		return d
+	}
+	return duration
+}
+
+// Returns the time value by name, it is parsed by the layout, such as time.RFC3339.
+func (in *Input) Time(name string, layout string, def ...time.Time) time.Time {
+	var d time.Time
+	if len(def) > 0 {
+		d = def[0]
+	}
+	value, ok := in.value(name)
+	if !ok {
+		return d
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		in.fail(name, "time", "%s must be a time in format "+layout+".")
+		return d
+	}
+	return t
+}
+
+// Returns the first non-empty value by name.
+func (in *Input) value(name string) (string, bool) {
+	values := in.lookup(name)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+	return values[0], true
+}
+
+func (in *Input) fail(name, rule, format string) {
+	in.errs = append(in.errs, &FieldError{
+		Field:   name,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, in.source+" "+strconv.Quote(name)),
+	})
+}
+
+// InputErrors is returned by Input.Err if there are malformed values.
+type InputErrors []*FieldError
+
+func (errs InputErrors) Error() string {
+	return ValidationErrors(errs).Error()
+}
+
+// Convert to HTTPError with status bad request, the field errors are set as details.
+func (errs InputErrors) HTTPError() *HTTPError {
+	return &HTTPError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_input",
+		Message: "The request contains malformed values.",
+		Details: errs,
+		Cause:   errs,
+	}
+}
+
+// Parse the boolean value strictly, the accepted values are "1", "t", "true", "on", "yes", "y"
+// and "0", "f", "false", "off", "no", "n" (case insensitive), others are treated as error.
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "t", "true", "on", "yes", "y":
+		return true, nil
+	case "0", "f", "false", "off", "no", "n":
+		return false, nil
+	}
+	return false, &strconv.NumError{Func: "ParseBool", Num: value, Err: strconv.ErrSyntax}
+}
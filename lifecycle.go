@@ -0,0 +1,54 @@
+package clevergo
+
+import "time"
+
+// ActionEvent describes the lifecycle of an action.
+type ActionEvent struct {
+	Action   Action
+	Context  *Context
+	Start    time.Time     // the time when the action started.
+	Duration time.Duration // the duration of request, it is zero in the started event.
+	Status   int           // the sent status, it is zero in the started event or if nothing was sent.
+	Error    *HTTPError    // the rendered error, it is nil if there is no error.
+	writer   *statusWriter // records the sent status, such as the redirect's.
+}
+
+// ActionListener observes the lifecycle of actions, such as metrics and tracing plugins.
+// The listeners are invoked synchronously, the event and its context must not be referenced after returning.
+type ActionListener interface {
+	ActionStarted(event *ActionEvent)
+	ActionFinished(event *ActionEvent) // invoked after the response was flushed, even if the action panics.
+}
+
+// Emit the started event, nil will be returned if there is no listener.
+func (a *Application) startAction(action Action, ctx *Context) *ActionEvent {
+	if len(a.listeners) == 0 {
+		return nil
+	}
+
+	event := &ActionEvent{
+		Action:  action,
+		Context: ctx,
+		Start:   time.Now(),
+		writer:  &statusWriter{ResponseWriter: ctx.Response.writer},
+	}
+	ctx.Response.writer = event.writer
+	for i := 0; i < len(a.listeners); i++ {
+		a.listeners[i].ActionStarted(event)
+	}
+	return event
+}
+
+// Emit the finished event, nothing will be done if the event is nil.
+func (a *Application) finishAction(event *ActionEvent) {
+	if event == nil {
+		return
+	}
+
+	event.Duration = time.Since(event.Start)
+	event.Status = event.writer.status
+	event.Error = event.Context.httpError
+	for i := 0; i < len(a.listeners); i++ {
+		a.listeners[i].ActionFinished(event)
+	}
+}
@@ -20,5 +20,6 @@ func NewMethodSkipMiddlewares(method string, middlewares SkipMiddlewares) Method
 
 // Middleware Interface.
 type Middleware interface {
+	ID() string                  // middleware's ID, the actions can skip the middleware by it.
 	Handle(next Handler) Handler // handle request.
 }
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/clevergo/clevergo"
+)
+
+var (
+	BodyLimitMiddlewareID = "BodyLimitMiddleware"
+)
+
+// BodyLimitMiddleware overrides the max size of request's body, it is usually attached to specific actions,
+// see also Controller's Middlewares().
+type BodyLimitMiddleware struct {
+	MaxSize int64 // the max size in bytes, zero means unlimited.
+}
+
+func NewBodyLimitMiddleware(maxSize int64) *BodyLimitMiddleware {
+	return &BodyLimitMiddleware{
+		MaxSize: maxSize,
+	}
+}
+
+func (blm *BodyLimitMiddleware) ID() string {
+	return BodyLimitMiddlewareID
+}
+
+func (blm *BodyLimitMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
+	return clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+		if err := ctx.SetMaxBodySize(blm.MaxSize); err != nil {
+			ctx.Error(err)
+			return
+		}
+		next.Handle(ctx)
+	})
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/clevergo"
+)
+
+type itemController struct {
+	clevergo.RestController
+}
+
+func (c *itemController) Middlewares() map[string][]clevergo.Middleware {
+	return map[string][]clevergo.Middleware{
+		"PUT": {NewBodyLimitMiddleware(16)},
+	}
+}
+
+func (c *itemController) Post() string {
+	return "post"
+}
+
+func (c *itemController) Put() string {
+	return c.Request().FormValue("name")
+}
+
+// The limit of the simulated method should be applied before the form is parsed.
+func TestBodyLimitMiddlewareSimulateMethod(t *testing.T) {
+	app := clevergo.NewApplication()
+	app.RegisterRestController("/items", &itemController{})
+	app.Run()
+
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"foo", http.StatusOK},
+		{strings.Repeat("x", 32), http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/items?_method=PUT", strings.NewReader("name="+test.name))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("name %q: status = %d, expected %d", test.name, w.Code, test.status)
+		}
+		if test.status == http.StatusOK && w.Body.String() != `"foo"` {
+			t.Errorf("name %q: body = %s, expected \"foo\"", test.name, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/clevergo/clevergo"
+)
+
+var (
+	CompressMiddlewareID = "CompressMiddleware"
+)
+
+// The content types those are compressed already.
+var defaultCompressSkipTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"video/*", "audio/*", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-7z-compressed",
+	"application/x-rar-compressed", "application/x-bzip2", "application/pdf",
+}
+
+// CompressMiddleware compresses the responses by gzip or deflate which is negotiated from Accept-Encoding.
+// The bodies smaller than MinSize, the content types in SkipTypes, the partial contents and the responses
+// which are encoded already are not compressed. The streaming responses are compressed and flushed as well.
+//
+// It works for the actions if it is registered as the application's middleware, and for all requests
+// including the static files if it is registered as the pre-router middleware.
+// The response is sent and the compression is finished before it returns, so that the outer middlewares
+// see the whole compressed response, such as TimeoutMiddleware, and the later errors can not be rendered.
+type CompressMiddleware struct {
+	Level     int      // the compression level, such as gzip.DefaultCompression.
+	MinSize   int      // the min size of body in bytes.
+	SkipTypes []string // the content types which are not compressed, such as "image/png" and "video/*".
+}
+
+func NewCompressMiddleware() *CompressMiddleware {
+	return &CompressMiddleware{
+		Level:     gzip.DefaultCompression,
+		MinSize:   1024,
+		SkipTypes: defaultCompressSkipTypes,
+	}
+}
+
+func (cm *CompressMiddleware) ID() string {
+	return CompressMiddlewareID
+}
+
+func (cm *CompressMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
+	return clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+		// The response is compressed by the outer middleware already.
+		if _, ok := ctx.Response.Writer().(*compressWriter); ok {
+			next.Handle(ctx)
+			return
+		}
+
+		addVary(ctx.Response.Header(), "Accept-Encoding")
+
+		encoding := negotiateEncoding(ctx.Request.Header.Get("Accept-Encoding"))
+		if encoding == "" || ctx.Request.Method == "HEAD" {
+			next.Handle(ctx)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: ctx.Response.Writer(),
+			middleware:     cm,
+			encoding:       encoding,
+		}
+		ctx.Response.SetWriter(cw)
+		finished := false
+		defer func() {
+			if !finished {
+				// The handler panicked, the error will be rendered without compression.
+				cw.close()
+				ctx.Response.SetWriter(cw.ResponseWriter)
+			}
+		}()
+
+		next.Handle(ctx)
+
+		// Send the response and finish the compression.
+		ctx.Flush()
+		cw.close()
+		ctx.Response.SetWriter(cw.ResponseWriter)
+		ctx.Response.SetCancel(true)
+		finished = true
+	})
+}
+
+// Add the request header's name to the Vary header unless it is listed already.
+func addVary(header http.Header, name string) {
+	for _, value := range strings.Split(strings.Join(header["Vary"], ","), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), name) {
+			return
+		}
+	}
+	header.Add("Vary", name)
+}
+
+// Returns a boolean indicating whether the content type matches one of the skipped types, such as "video/*".
+func skipType(contentType string, types []string) bool {
+	for _, t := range types {
+		if t == "*/*" || strings.EqualFold(t, contentType) {
+			return true
+		}
+		if strings.HasSuffix(t, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the preferred encoding of gzip and deflate which is accepted by the client, gzip is preferred
+// if the qualities are equal, empty means that neither is accepted.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		qualities[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the beginning of body until MinSize to decide whether to compress.
+type compressWriter struct {
+	http.ResponseWriter
+	middleware  *CompressMiddleware
+	encoding    string
+	status      int
+	wroteHeader bool
+	buf         []byte
+	decided     bool
+	compressor  io.WriteCloser // nil if the response is not compressed.
+	hijacked    bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		return cw.write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.middleware.MinSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush the compressor and the underlying writer, the streaming response is compressed regardless of its size.
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.decide(true)
+	}
+	if flusher, ok := cw.compressor.(interface {
+		Flush() error
+	}); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack the connection, such as WebSocket's handshake, the response will not be compressed.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		cw.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Decide whether to compress, send the status and headers, and write the buffered body.
+func (cw *compressWriter) decide(largeEnough bool) error {
+	cw.decided = true
+	header := cw.Header()
+
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if largeEnough && cw.compressible() {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", cw.encoding)
+		if cw.encoding == "gzip" {
+			cw.compressor, _ = gzip.NewWriterLevel(cw.ResponseWriter, cw.middleware.Level)
+		} else {
+			cw.compressor, _ = zlib.NewWriterLevel(cw.ResponseWriter, cw.middleware.Level)
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := cw.write(buf)
+	return err
+}
+
+// Returns a boolean indicating whether the response can be compressed.
+func (cw *compressWriter) compressible() bool {
+	header := cw.Header()
+	if cw.status < 200 || cw.status == http.StatusNoContent || cw.status == http.StatusPartialContent || cw.status >= 300 {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return true
+	}
+	return !skipType(contentType, cw.middleware.SkipTypes)
+}
+
+func (cw *compressWriter) write(p []byte) (int, error) {
+	if cw.compressor != nil {
+		return cw.compressor.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Write the rest of body, the buffered body which is smaller than MinSize is not compressed.
+func (cw *compressWriter) close() {
+	if cw.hijacked || !cw.wroteHeader {
+		return
+	}
+	if !cw.decided {
+		cw.decide(false)
+	}
+	if cw.compressor != nil {
+		cw.compressor.Close()
+	}
+}
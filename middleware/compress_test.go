@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/clevergo"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                              "",
+		"gzip, deflate, br":             "gzip",
+		"deflate":                       "deflate",
+		"gzip;q=0.5, deflate":           "deflate",
+		"gzip;q=0, *":                   "deflate",
+		"identity":                      "",
+		"*;q=0":                         "",
+		"br, GZIP;q=0.8, deflate;q=0.8": "gzip",
+	}
+	for header, expected := range tests {
+		if encoding := negotiateEncoding(header); encoding != expected {
+			t.Errorf("negotiateEncoding(%q) = %q, expected %q", header, encoding, expected)
+		}
+	}
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	large := strings.Repeat("compress me ", 200)
+	tests := []struct {
+		acceptEncoding string
+		contentType    string
+		body           string
+		stream         bool
+		encoding       string
+	}{
+		{"gzip", "application/json", large, false, "gzip"},
+		{"deflate", "text/html", large, false, "deflate"},
+		{"gzip", "text/html", "small", false, ""},
+		{"gzip", "image/png", large, false, ""},
+		{"", "text/html", large, false, ""},
+		{"gzip", "text/event-stream", "data: small\n\n", true, "gzip"},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", test.acceptEncoding)
+		w := httptest.NewRecorder()
+		ctx := clevergo.NewContext(nil, w, r, nil)
+
+		test := test
+		NewCompressMiddleware().Handle(clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+			ctx.Response.Header().Set("Content-Type", test.contentType)
+			if test.stream {
+				ctx.Response.Write([]byte(test.body))
+				ctx.Response.Flush()
+				return
+			}
+			ctx.Response.SetBody(test.body)
+		})).Handle(ctx)
+		ctx.Flush()
+
+		if encoding := w.Header().Get("Content-Encoding"); encoding != test.encoding {
+			t.Errorf("%d: Content-Encoding = %q, expected %q", i, encoding, test.encoding)
+			continue
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("%d: Vary = %q", i, vary)
+		}
+
+		var reader io.Reader = w.Body
+		switch test.encoding {
+		case "gzip":
+			reader, _ = gzip.NewReader(w.Body)
+		case "deflate":
+			reader, _ = zlib.NewReader(w.Body)
+		}
+		body, err := ioutil.ReadAll(reader)
+		if err != nil || string(body) != test.body {
+			t.Errorf("%d: body = %q, %v", i, body, err)
+		}
+	}
+}
+
+func TestCompressMiddlewareStaticFiles(t *testing.T) {
+	app := clevergo.NewApplication()
+	app.AddPreMiddleware(NewCompressMiddleware())
+	app.AddHandler("/static", []string{"GET"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "app.js", time.Time{}, strings.NewReader(strings.Repeat("var a = 1;\n", 200)))
+	}))
+	app.Run()
+
+	r := httptest.NewRequest("GET", "/static", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+		t.Fatalf("headers = %v, expected gzip without Content-Length", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := ioutil.ReadAll(reader); !strings.HasPrefix(string(body), "var a = 1;") {
+		t.Errorf("body = %q", body)
+	}
+}
+
+// The compression should be finished before the timeout middleware merges the buffered response.
+func TestCompressMiddlewareInTimeout(t *testing.T) {
+	large := strings.Repeat("compress me ", 200)
+	for _, stream := range []bool{false, true} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		ctx := clevergo.NewContext(nil, w, r, nil)
+
+		handler := NewCompressMiddleware().Handle(clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+			if stream {
+				ctx.Response.Write([]byte(large))
+				return
+			}
+			ctx.Response.SetBody(large)
+		}))
+		NewTimeoutMiddleware(time.Second).Handle(handler).Handle(ctx)
+		ctx.Flush()
+
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("stream %v: %s", stream, err)
+		}
+		if body, err := ioutil.ReadAll(reader); err != nil || string(body) != large {
+			t.Errorf("stream %v: body = %q, %v", stream, body, err)
+		}
+	}
+}
+
+func TestCompressSkipTypeAndVary(t *testing.T) {
+	tests := []struct {
+		contentType string
+		skipped     bool
+	}{
+		{"video/mp4", true},
+		{"IMAGE/PNG", true},
+		{"text/html", false},
+		{"videos/mp4", false},
+	}
+	for _, test := range tests {
+		if skipped := skipType(test.contentType, defaultCompressSkipTypes); skipped != test.skipped {
+			t.Errorf("skipType(%q) = %t, expected %t", test.contentType, skipped, test.skipped)
+		}
+	}
+
+	header := http.Header{"Vary": {"Origin, accept-encoding"}}
+	addVary(header, "Accept-Encoding")
+	if len(header["Vary"]) != 1 {
+		t.Errorf("Vary = %q, expected the listed name is not added again", header["Vary"])
+	}
+}
@@ -10,7 +10,8 @@ import (
 )
 
 var (
-	errCSRFInvalid = "Unable to verify your data submission."
+	CSRFMiddlewareID = "CSRFMiddleware"
+	errCSRFInvalid   = "Unable to verify your data submission."
 )
 
 type CSRFMiddleware struct {
@@ -24,6 +25,10 @@ type CSRFMiddleware struct {
 	ErrorInvalid string
 }
 
+func (csrf *CSRFMiddleware) ID() string {
+	return CSRFMiddlewareID
+}
+
 func (csrf *CSRFMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
 	return clevergo.HandlerFunc(func(ctx *clevergo.Context) {
 		var trueToken string
@@ -51,7 +56,7 @@ func (csrf *CSRFMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
 			}
 		} else {
 			csrfToken := GenerateCSRFToken(csrf.MaskLen, trueToken)
-			ctx.Values[csrf.Key] = csrfToken
+			ctx.SetValue(csrf.Key, csrfToken)
 			ctx.Session.Set(csrf.SessionKey, trueToken)
 		}
 		next.Handle(ctx)
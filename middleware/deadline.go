@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/clevergo/clevergo"
+	"time"
+)
+
+var (
+	DeadlineMiddlewareID = "DeadlineMiddleware"
+)
+
+// DeadlineMiddleware sets the deadline of request's context, the context will be done after the timeout,
+// the actions should check the context's cancellation, or pass the context returned by Context.Ctx
+// to the functions which accept context.Context. It can be attached to specific actions, see also Controller's Middlewares().
+type DeadlineMiddleware struct {
+	Timeout time.Duration
+}
+
+func NewDeadlineMiddleware(timeout time.Duration) *DeadlineMiddleware {
+	return &DeadlineMiddleware{
+		Timeout: timeout,
+	}
+}
+
+func (dm *DeadlineMiddleware) ID() string {
+	return DeadlineMiddlewareID
+}
+
+func (dm *DeadlineMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
+	return clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+		if dm.Timeout > 0 {
+			ctx.SetTimeout(dm.Timeout)
+		}
+		next.Handle(ctx)
+	})
+}
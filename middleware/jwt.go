@@ -3,6 +3,7 @@ package middleware
 import (
 	"github.com/clevergo/clevergo"
 	"github.com/clevergo/jwt"
+	"net/http"
 	"strings"
 )
 
@@ -24,13 +25,12 @@ func NewJWTMiddleware() *JWTMiddleware {
 	}
 }
 
+func (jm *JWTMiddleware) ID() string {
+	return JWTMiddlewareID
+}
+
 func (jm *JWTMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
 	return clevergo.HandlerFunc(func(ctx *clevergo.Context) {
-		if _, canSkip := ctx.SkipMiddlewares[JWTMiddlewareID]; canSkip {
-			next.Handle(ctx)
-			return
-		}
-
 		// Try to get JWT raw token from URL query string.
 		rawToken := ctx.Request.FormValue(jm.urlKey)
 		if len(rawToken) < 0 {
@@ -49,20 +49,20 @@ func (jm *JWTMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
 
 		// Check raw token is valid.
 		if len(rawToken) == 0 {
-			ctx.Response.Unauthorized()
+			jm.unauthorized(ctx, "")
 			return
 		}
 
 		// Get JWT by raw token
 		token, err := jwt.NewTokenByRaw(ctx.JWT(), rawToken)
 		if err != nil {
-			ctx.Response.Unauthorized(err.Error())
+			jm.unauthorized(ctx, err.Error())
 			return
 		}
 
 		// Validate JWT.
 		if err = token.Validate(); err != nil {
-			ctx.Response.Unauthorized(err.Error())
+			jm.unauthorized(ctx, err.Error())
 			return
 		}
 
@@ -71,3 +71,10 @@ func (jm *JWTMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
 		next.Handle(ctx)
 	})
 }
+
+// Respond unauthorized by the error handler, it is rendered as problem details if the action's errors are,
+// see also clevergo.ProblemOptions.
+func (jm *JWTMiddleware) unauthorized(ctx *clevergo.Context, message string) {
+	ctx.Response.Header().Set("WWW-Authenticate", "Bearer")
+	ctx.Error(clevergo.NewHTTPError(http.StatusUnauthorized, message))
+}
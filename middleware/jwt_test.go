@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/clevergo"
+)
+
+func TestJWTMiddlewareUnauthorized(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"application/json", "application/json"},
+		{"text/html", "text/html"},
+	}
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", test.accept)
+		ctx := clevergo.NewContext(nil, rw, r, nil)
+
+		handler := NewJWTMiddleware().Handle(clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+			t.Error("the next handler should not be invoked without token")
+		}))
+		handler.Handle(ctx)
+		ctx.Flush()
+
+		if rw.Code != http.StatusUnauthorized || rw.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("Accept %s: status = %d, expected %d with challenge", test.accept, rw.Code, http.StatusUnauthorized)
+		}
+		// The error is rendered by the error handler instead of problem details.
+		if contentType := rw.Header().Get("Content-Type"); !strings.HasPrefix(contentType, test.contentType) {
+			t.Errorf("Accept %s: content type = %q, expected %s", test.accept, contentType, test.contentType)
+		}
+	}
+}
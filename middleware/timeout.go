@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/clevergo/clevergo"
+)
+
+var (
+	TimeoutMiddlewareID = "TimeoutMiddleware"
+)
+
+// TimeoutMiddleware limits the duration of handling request, the request's context will be canceled after
+// the timeout, and the error with status Status will be rendered by the error handler if the action has not
+// finished yet, the writes of the timed out action are discarded, see also Context.HandleTimeout.
+//
+// It can be registered as the application's middleware, and the actions can override the timeout by skipping
+// it and attaching another TimeoutMiddleware, see also Controller's SkipMiddlewares() and Middlewares().
+// The WebSocket routes skip it by default, see also WebSocketOptions.
+type TimeoutMiddleware struct {
+	Timeout time.Duration
+	Status  int // the response's status of timeout, defaults to service unavailable.
+}
+
+func NewTimeoutMiddleware(timeout time.Duration) *TimeoutMiddleware {
+	return &TimeoutMiddleware{
+		Timeout: timeout,
+		Status:  http.StatusServiceUnavailable,
+	}
+}
+
+func (tm *TimeoutMiddleware) ID() string {
+	return TimeoutMiddlewareID
+}
+
+func (tm *TimeoutMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
+	return clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+		if tm.Timeout <= 0 {
+			next.Handle(ctx)
+			return
+		}
+
+		start := time.Now()
+		err := ctx.HandleTimeout(tm.Timeout, next)
+		if err == nil {
+			return
+		}
+		if err != context.DeadlineExceeded {
+			// The client has gone away, nothing can be sent.
+			ctx.Response.SetCancel(true)
+			return
+		}
+
+		tm.logTimeout(ctx, time.Since(start))
+		status := tm.Status
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.Response.SetCancel(false)
+		ctx.Error(clevergo.NewHTTPError(status, ""))
+	})
+}
+
+// Log the timeout as a warning, it is dropped if the log is disabled.
+func (tm *TimeoutMiddleware) logTimeout(ctx *clevergo.Context, elapsed time.Duration) {
+	if ctx.Log == nil {
+		return
+	}
+	route := ctx.Request.Method + " " + ctx.Request.URL.Path
+	if a := ctx.Action(); a != nil {
+		route += " (" + a.Controller().FullName() + "." + a.PrettyName() + ")"
+	}
+	ctx.Log.Warn(fmt.Sprintf("Request timed out: %s after %s, the timeout is %s.", route, elapsed, tm.Timeout))
+}
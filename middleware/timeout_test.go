@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/clevergo"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	late := make(chan error, 1)
+	tests := []struct {
+		delay  time.Duration
+		status int
+		body   string
+	}{
+		{0, http.StatusOK, "done"},
+		{100 * time.Millisecond, http.StatusServiceUnavailable, ""},
+	}
+	for i, test := range tests {
+		delay := test.delay
+		handler := NewTimeoutMiddleware(20 * time.Millisecond).Handle(clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+			ctx.Response.Header().Set("X-Action", "1")
+			if delay > 0 {
+				<-ctx.Ctx().Done()
+				time.Sleep(delay)
+				_, err := ctx.Response.Writer().Write([]byte("late"))
+				late <- err
+				return
+			}
+			ctx.Response.SetBody("done")
+		}))
+
+		w := httptest.NewRecorder()
+		ctx := clevergo.NewContext(nil, w, httptest.NewRequest("GET", "/", nil), nil)
+		handler.Handle(ctx)
+		ctx.Flush()
+
+		if w.Code != test.status {
+			t.Errorf("%d: status = %d, expected %d", i, w.Code, test.status)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%d: body = %q, expected %q", i, w.Body.String(), test.body)
+		}
+		if test.delay > 0 {
+			if err := <-late; err != http.ErrHandlerTimeout {
+				t.Errorf("%d: late write returns %v, expected %v", i, err, http.ErrHandlerTimeout)
+			}
+			if w.Header().Get("X-Action") != "" {
+				t.Errorf("%d: the header of timed out action is sent", i)
+			}
+		} else if w.Header().Get("X-Action") != "1" {
+			t.Errorf("%d: the header of action is not sent", i)
+		}
+	}
+}
+
+// The application's TimeoutMiddleware is skipped by the WebSocket routes unless it is kept explicitly.
+func TestTimeoutMiddlewareWebSocket(t *testing.T) {
+	tests := []struct {
+		skip   clevergo.SkipMiddlewares
+		status int
+	}{
+		{nil, http.StatusSwitchingProtocols},
+		{clevergo.SkipMiddlewares{TimeoutMiddlewareID: false}, http.StatusInternalServerError},
+	}
+	for i, test := range tests {
+		app := clevergo.NewApplication()
+		app.AddMiddleware(NewTimeoutMiddleware(time.Second))
+		options := clevergo.NewWebSocketOptions()
+		options.SkipMiddlewares = test.skip
+		app.AddWebSocket("/ws", func(ws *clevergo.WebSocket) {}, options)
+		app.Run()
+		server := httptest.NewServer(app)
+
+		conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: " + strings.TrimPrefix(server.URL, "http://") +
+			"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+		response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Errorf("%d: %s", i, err)
+		} else if response.StatusCode != test.status {
+			t.Errorf("%d: status of handshake = %d, expected %d", i, response.StatusCode, test.status)
+		}
+		conn.Close()
+		server.Close()
+	}
+}
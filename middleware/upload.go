@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/clevergo/clevergo"
+)
+
+var (
+	UploadMiddlewareID = "UploadMiddleware"
+)
+
+// UploadMiddleware overrides the upload options of the request, it is usually attached to specific actions,
+// see also Controller's Middlewares().
+type UploadMiddleware struct {
+	Options clevergo.UploadOptions
+}
+
+func NewUploadMiddleware(options clevergo.UploadOptions) *UploadMiddleware {
+	return &UploadMiddleware{
+		Options: options,
+	}
+}
+
+func (um *UploadMiddleware) ID() string {
+	return UploadMiddlewareID
+}
+
+func (um *UploadMiddleware) Handle(next clevergo.Handler) clevergo.Handler {
+	return clevergo.HandlerFunc(func(ctx *clevergo.Context) {
+		ctx.Request.SetUploadOptions(um.Options)
+		next.Handle(ctx)
+	})
+}
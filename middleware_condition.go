@@ -0,0 +1,120 @@
+package clevergo
+
+import (
+	"net/textproto"
+	"path"
+	"strings"
+)
+
+// Condition reports whether the middleware should be applied to the current request.
+type Condition func(ctx *Context) bool
+
+// ConditionalMiddleware applies the middleware only when all of the conditions are satisfied,
+// otherwise the request is passed to the next handler directly.
+type ConditionalMiddleware struct {
+	middleware Middleware
+	conditions []Condition
+}
+
+func NewConditionalMiddleware(middleware Middleware, conditions ...Condition) *ConditionalMiddleware {
+	return &ConditionalMiddleware{
+		middleware: middleware,
+		conditions: conditions,
+	}
+}
+
+// Returns the wrapped middleware's ID, so that it can be skipped as usual.
+func (cm *ConditionalMiddleware) ID() string {
+	return cm.middleware.ID()
+}
+
+func (cm *ConditionalMiddleware) Handle(next Handler) Handler {
+	handler := cm.middleware.Handle(next)
+
+	return HandlerFunc(func(ctx *Context) {
+		for i := 0; i < len(cm.conditions); i++ {
+			if !cm.conditions[i](ctx) {
+				next.Handle(ctx)
+				return
+			}
+		}
+		handler.Handle(ctx)
+	})
+}
+
+// Returns a condition which is satisfied if the request's path matches one of the patterns.
+// The pattern syntax is the same as path.Match, for example, "/users/*".
+func PathCondition(patterns ...string) Condition {
+	return func(ctx *Context) bool {
+		for i := 0; i < len(patterns); i++ {
+			if matched, err := path.Match(patterns[i], ctx.Request.URL.Path); err == nil && matched {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns a condition which is satisfied if the request's path starts with one of the prefixes.
+func PathPrefixCondition(prefixes ...string) Condition {
+	return func(ctx *Context) bool {
+		for i := 0; i < len(prefixes); i++ {
+			if strings.HasPrefix(ctx.Request.URL.Path, prefixes[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns a condition which is satisfied if the request's method is one of the methods(case insensitive).
+func MethodCondition(methods ...string) Condition {
+	return func(ctx *Context) bool {
+		for i := 0; i < len(methods); i++ {
+			if strings.EqualFold(methods[i], ctx.Request.Method) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns a condition which is satisfied if the request's host(without port) is one of the hosts.
+func HostCondition(hosts ...string) Condition {
+	return func(ctx *Context) bool {
+		host := strings.Split(ctx.Request.ForwardedHost(), ":")[0]
+		for i := 0; i < len(hosts); i++ {
+			if strings.EqualFold(hosts[i], host) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns a condition which is satisfied if the request's header named name is equal to value.
+// If value is empty, the condition is satisfied as long as the header is present.
+func HeaderCondition(name, value string) Condition {
+	return func(ctx *Context) bool {
+		values, ok := ctx.Request.Header[textproto.CanonicalMIMEHeaderKey(name)]
+		if !ok {
+			return false
+		}
+		if len(value) == 0 {
+			return true
+		}
+		for i := 0; i < len(values); i++ {
+			if values[i] == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns a condition which is satisfied if the condition is not satisfied.
+func NotCondition(condition Condition) Condition {
+	return func(ctx *Context) bool {
+		return !condition(ctx)
+	}
+}
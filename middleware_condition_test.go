@@ -0,0 +1,58 @@
+package clevergo
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+type markMiddleware struct{}
+
+func (m markMiddleware) ID() string {
+	return "MarkMiddleware"
+}
+
+func (m markMiddleware) Handle(next Handler) Handler {
+	return HandlerFunc(func(ctx *Context) {
+		ctx.SetValue("marked", true)
+		next.Handle(ctx)
+	})
+}
+
+func TestConditionalMiddleware(t *testing.T) {
+	tests := []struct {
+		conditions []Condition
+		method     string
+		url        string
+		header     string
+		expected   bool
+	}{
+		{nil, "GET", "/", "", true},
+		{[]Condition{PathCondition("/users/*")}, "GET", "/users/1", "", true},
+		{[]Condition{PathCondition("/users/*")}, "GET", "/users/1/posts", "", false},
+		{[]Condition{PathPrefixCondition("/api/", "/admin/")}, "GET", "/admin/users", "", true},
+		{[]Condition{PathPrefixCondition("/api/")}, "GET", "/users", "", false},
+		{[]Condition{MethodCondition("post", "put")}, "PUT", "/", "", true},
+		{[]Condition{MethodCondition("post")}, "GET", "/", "", false},
+		{[]Condition{HostCondition("example.com")}, "GET", "http://EXAMPLE.com:8080/", "", true},
+		{[]Condition{HostCondition("example.com")}, "GET", "http://example.org/", "", false},
+		{[]Condition{HeaderCondition("X-Requested-With", "")}, "GET", "/", "XMLHttpRequest", true},
+		{[]Condition{HeaderCondition("X-Requested-With", "XMLHttpRequest")}, "GET", "/", "fetch", false},
+		{[]Condition{HeaderCondition("X-Requested-With", "")}, "GET", "/", "", false},
+		{[]Condition{NotCondition(PathPrefixCondition("/static/"))}, "GET", "/static/app.js", "", false},
+		{[]Condition{MethodCondition("GET"), PathPrefixCondition("/api/")}, "GET", "/users", "", false},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, nil)
+		if len(test.header) > 0 {
+			r.Header.Set("X-Requested-With", test.header)
+		}
+		ctx := NewContext(nil, httptest.NewRecorder(), r, nil)
+
+		handler := NewConditionalMiddleware(markMiddleware{}, test.conditions...).Handle(HandlerFunc(func(ctx *Context) {}))
+		handler.Handle(ctx)
+
+		if marked := ctx.Value("marked") != nil; marked != test.expected {
+			t.Errorf("%d. %s %s: applied = %t, expected %t", i, test.method, test.url, marked, test.expected)
+		}
+	}
+}
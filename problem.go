@@ -0,0 +1,80 @@
+package clevergo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Problem is the problem details of RFC 7807, it is responded as "application/problem+json".
+type Problem struct {
+	Type     string      `json:"type"`               // URI reference that identifies the problem type.
+	Title    string      `json:"title"`              // Short summary of the problem type.
+	Status   int         `json:"status"`             // HTTP status code.
+	Detail   string      `json:"detail,omitempty"`   // Explanation specific to this occurrence of the problem.
+	Instance string      `json:"instance,omitempty"` // URI reference that identifies this occurrence of the problem.
+	Code     string      `json:"code,omitempty"`     // Extension: application specific error code.
+	Details  interface{} `json:"details,omitempty"`  // Extension: additional details, such as the invalid fields.
+}
+
+// ProblemOptions specifies which errors are rendered as problem details by default.
+type ProblemOptions struct {
+	Rest        bool   // errors of restful controllers.
+	Routing     bool   // not found and method not allowed.
+	TypeBaseURI string // the problem's type is TypeBaseURI + error's code, it is "about:blank" if either one is empty.
+}
+
+func NewProblemOptions() ProblemOptions {
+	return ProblemOptions{
+		Rest:        true,
+		Routing:     false,
+		TypeBaseURI: "",
+	}
+}
+
+// Create problem details from the error.
+// The detail of internal server error is hidden in production mode.
+func NewProblem(ctx *Context, err *HTTPError, typeBaseURI string) *Problem {
+	problem := &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(err.Status),
+		Status:   err.Status,
+		Detail:   err.Message,
+		Instance: ctx.Request.URL.RequestURI(),
+		Code:     err.Code,
+		Details:  err.Details,
+	}
+
+	if len(typeBaseURI) > 0 && len(err.Code) > 0 {
+		problem.Type = strings.TrimSuffix(typeBaseURI, "/") + "/" + err.Code
+	}
+
+	if err.Status >= http.StatusInternalServerError && Configuration.mode != ModeDev {
+		problem.Detail = ""
+		problem.Details = nil
+	} else if problem.Detail == problem.Title {
+		problem.Detail = ""
+	}
+
+	return problem
+}
+
+// The default problem handler renders the error as "application/problem+json".
+func DefaultProblemHandler(ctx *Context, err *HTTPError) {
+	typeBaseURI := ""
+	if ctx.app != nil {
+		typeBaseURI = ctx.app.problemOptions.TypeBaseURI
+	}
+
+	data, e := json.Marshal(NewProblem(ctx, err, typeBaseURI))
+	if e != nil {
+		// The details cannot be marshaled.
+		problem := NewProblem(ctx, err, typeBaseURI)
+		problem.Details = nil
+		data, _ = json.Marshal(problem)
+	}
+
+	ctx.Response.SetStatus(err.Status)
+	ctx.Response.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	ctx.Response.body = string(data)
+}
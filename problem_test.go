@@ -0,0 +1,72 @@
+package clevergo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	defer func(mode int) {
+		Configuration.mode = mode
+	}(Configuration.mode)
+
+	ctx := NewContext(nil, httptest.NewRecorder(), httptest.NewRequest("GET", "/users?page=2", nil), nil)
+	invalid := &HTTPError{Status: http.StatusUnprocessableEntity, Code: "invalid_input", Message: "The input is invalid.", Details: []string{"name"}}
+	internal := WrapError(http.StatusInternalServerError, errors.New("database is down"))
+
+	tests := []struct {
+		mode        int
+		err         *HTTPError
+		typeBaseURI string
+		expected    Problem
+	}{
+		{ModePro, invalid, "https://example.com/problems/", Problem{
+			Type: "https://example.com/problems/invalid_input", Title: "Unprocessable Entity", Status: http.StatusUnprocessableEntity,
+			Detail: "The input is invalid.", Instance: "/users?page=2", Code: "invalid_input",
+		}},
+		{ModePro, NewHTTPError(http.StatusNotFound, ""), "https://example.com/problems/", Problem{
+			Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Instance: "/users?page=2",
+		}},
+		{ModePro, internal, "", Problem{
+			Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Instance: "/users?page=2",
+		}},
+		{ModeDev, internal, "", Problem{
+			Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+			Detail: "database is down", Instance: "/users?page=2",
+		}},
+	}
+	for i, test := range tests {
+		Configuration.mode = test.mode
+		problem := NewProblem(ctx, test.err, test.typeBaseURI)
+		details := problem.Details
+		problem.Details = nil
+		if *problem != test.expected {
+			t.Errorf("%d. NewProblem() = %+v, expected %+v", i, *problem, test.expected)
+		}
+		if (details != nil) != (test.err == invalid) {
+			t.Errorf("%d. NewProblem() details = %v", i, details)
+		}
+	}
+}
+
+func TestRoutingProblem(t *testing.T) {
+	app := NewApplication()
+	options := NewProblemOptions()
+	options.Routing = true
+	app.SetProblemOptions(options)
+	app.Run()
+
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, httptest.NewRequest("GET", "/missing", nil))
+
+	if rw.Code != http.StatusNotFound || rw.Header().Get("Content-Type") != "application/problem+json; charset=utf-8" {
+		t.Fatalf("status = %d with content type %q, expected problem details", rw.Code, rw.Header().Get("Content-Type"))
+	}
+	problem := &Problem{}
+	if err := json.Unmarshal(rw.Body.Bytes(), problem); err != nil || problem.Status != http.StatusNotFound || problem.Instance != "/missing" {
+		t.Errorf("body = %s, expected the problem of not found", rw.Body.String())
+	}
+}
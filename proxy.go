@@ -0,0 +1,242 @@
+package clevergo
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies is a list of the networks of trusted proxies.
+// The forwarding headers, such as Forwarded, X-Forwarded-For, X-Forwarded-Proto, X-Forwarded-Host and X-Real-IP,
+// are only honored if the request comes from the trusted proxies.
+type TrustedProxies []*net.IPNet
+
+// Parse trusted proxies from CIDRs, a single IP address is also allowed, such as "127.0.0.1" or "::1".
+func ParseTrustedProxies(cidrs ...string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %q", cidr)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Returns a boolean indicating whether the ip belongs to the trusted proxies.
+func (tp TrustedProxies) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range tp {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the IP address of remote address.
+func (r *Request) remoteIP() net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// Returns a boolean indicating whether the request comes from a trusted proxy.
+func (r *Request) fromTrustedProxy() bool {
+	return len(r.trustedProxies) > 0 && r.trustedProxies.Contains(r.remoteIP())
+}
+
+// forwardedHop is an element of the forwarding chain, which is appended by a proxy.
+type forwardedHop struct {
+	node  string // the address of the node which sent the request to the proxy.
+	proto string // the scheme of the request which is received by the proxy.
+	host  string // the host of the request which is received by the proxy.
+}
+
+// Returns the forwarding chain, the Forwarded header (RFC 7239) is preferred to the X-Forwarded-* headers.
+// Every proxy appends its values, so the X-Forwarded-* headers are aligned from right to left.
+func forwardedChain(header http.Header) []forwardedHop {
+	if elements := headerValues(header, "Forwarded"); len(elements) > 0 {
+		chain := make([]forwardedHop, len(elements))
+		for i, element := range elements {
+			for _, pair := range strings.Split(element, ";") {
+				j := strings.IndexByte(pair, '=')
+				if j < 0 {
+					continue
+				}
+				value := strings.Trim(strings.TrimSpace(pair[j+1:]), `"`)
+				switch strings.ToLower(strings.TrimSpace(pair[:j])) {
+				case "for":
+					chain[i].node = value
+				case "proto":
+					chain[i].proto = value
+				case "host":
+					chain[i].host = value
+				}
+			}
+		}
+		return chain
+	}
+
+	nodes := headerValues(header, "X-Forwarded-For")
+	protos := headerValues(header, "X-Forwarded-Proto")
+	hosts := headerValues(header, "X-Forwarded-Host")
+	n := len(nodes)
+	if len(protos) > n {
+		n = len(protos)
+	}
+	if len(hosts) > n {
+		n = len(hosts)
+	}
+	chain := make([]forwardedHop, n)
+	for i := 1; i <= n; i++ {
+		hop := &chain[n-i]
+		if i <= len(nodes) {
+			hop.node = nodes[len(nodes)-i]
+		}
+		if i <= len(protos) {
+			hop.proto = protos[len(protos)-i]
+		}
+		if i <= len(hosts) {
+			hop.host = hosts[len(hosts)-i]
+		}
+	}
+	return chain
+}
+
+// Returns the hops of the forwarding chain those are appended by the trusted proxies, from the nearest proxy
+// to the outermost one. The chain is walked from right to left, the rightmost hop is appended by the remote peer,
+// and the walk stops after the hop whose node is not a trusted proxy, since the hops on its left may be forged.
+func (r *Request) trustedHops() []forwardedHop {
+	if !r.fromTrustedProxy() {
+		return nil
+	}
+
+	chain := forwardedChain(r.Header)
+	hops := make([]forwardedHop, 0, len(chain))
+	for i := len(chain) - 1; i >= 0; i-- {
+		hops = append(hops, chain[i])
+		if ip := parseNodeIP(chain[i].node); ip == nil || !r.trustedProxies.Contains(ip) {
+			break
+		}
+	}
+	return hops
+}
+
+// Returns the client's IP address.
+// If the request comes from a trusted proxy, the forwarding chain of Forwarded or X-Forwarded-For is walked
+// from right to left, and the first address which is not a trusted proxy is the client's address,
+// X-Real-IP is used if there is no forwarding chain.
+// Otherwise the address of the remote peer is returned.
+func (r *Request) ClientIP() string {
+	remoteIP := r.remoteIP()
+	if remoteIP == nil {
+		return r.RemoteAddr
+	}
+
+	hops := r.trustedHops()
+	if len(hops) == 0 || hops[0].node == "" {
+		if ip := parseNodeIP(r.Header.Get("X-Real-IP")); ip != nil && r.fromTrustedProxy() {
+			return ip.String()
+		}
+		return remoteIP.String()
+	}
+
+	client := remoteIP
+	for _, hop := range hops {
+		ip := parseNodeIP(hop.node)
+		if ip == nil {
+			// Obfuscated or unknown node, the chain can not be trusted any further.
+			break
+		}
+		client = ip
+	}
+	return client.String()
+}
+
+// Returns the scheme of the request, "http" or "https".
+// The Forwarded's proto or X-Forwarded-Proto which is set by the outermost trusted proxy is honored,
+// see also ClientIP.
+func (r *Request) Scheme() string {
+	proto := ""
+	for _, hop := range r.trustedHops() {
+		if hop.proto != "" {
+			proto = strings.ToLower(hop.proto)
+		}
+	}
+	if proto == "http" || proto == "https" {
+		return proto
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Returns the host which is requested by the client, it may contain the port.
+// The Forwarded's host or X-Forwarded-Host which is set by the outermost trusted proxy is honored,
+// otherwise the request's Host is returned, see also ClientIP.
+func (r *Request) ForwardedHost() string {
+	host := ""
+	for _, hop := range r.trustedHops() {
+		if hop.host != "" {
+			host = hop.host
+		}
+	}
+	if host != "" {
+		return host
+	}
+
+	return r.Request.Host
+}
+
+// Returns the comma separated values of the given header, the multiple headers are joined.
+func headerValues(header http.Header, name string) []string {
+	var values []string
+	for _, line := range header[http.CanonicalHeaderKey(name)] {
+		for _, value := range strings.Split(line, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// Parse the IP address of a node, such as "192.0.2.60", "192.0.2.60:4711", "[2001:db8::1]:4711" or "2001:db8::1".
+func parseNodeIP(node string) net.IP {
+	node = strings.TrimSpace(node)
+	if strings.HasPrefix(node, "[") {
+		if i := strings.IndexByte(node, ']'); i > 0 {
+			node = node[1:i]
+		}
+	} else if strings.Count(node, ":") == 1 {
+		node = node[:strings.IndexByte(node, ':')]
+	}
+	return net.ParseIP(node)
+}
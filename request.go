@@ -1,27 +1,112 @@
 package clevergo
 
 import (
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
+// The same as the net/http package's default max memory of multipart form.
+const defaultMaxMemory = 32 << 20 // 32 MB
+
 type Request struct {
 	*http.Request
+	uploadOptions  UploadOptions  // the limits of uploaded files.
+	trustedProxies TrustedProxies // the trusted proxies whose forwarding headers are honored.
+	formErr        error          // the error of parsing form.
 }
 
 func NewRequest(r *http.Request) *Request {
-	return &Request{r}
+	return &Request{
+		Request:       r,
+		uploadOptions: NewUploadOptions(),
+	}
 }
 
-// Get simulation method.
+// Get simulation method from the query parameter name or the X-HTTP-Method-Override header.
+// The request's body is never read, so that the method is known before the body's limits are applied,
+// a HTML form should put the method into its action's URL, such as "/users/1?_method=DELETE".
 func (r *Request) SimulateMethod(name string) string {
-	method := r.FormValue(name)
+	method := r.URL.Query().Get(name)
 	if len(method) == 0 {
-		method = r.PostFormValue(method)
+		method = r.Header.Get("X-HTTP-Method-Override")
 	}
 	return method
 }
 
+// Returns a boolean indicating whether r's content is a form(urlencoded or multipart).
+func (r *Request) IsForm() bool {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return contentType == "application/x-www-form-urlencoded" || contentType == "multipart/form-data"
+}
+
+// Parse the form, the HTTPError will be returned if the form is malformed or the body is too large.
+// The multipart form is parsed with the upload options, its body is limited by the options before parsing,
+// so that the too large body is rejected rather than spooled into temporary files.
+func (r *Request) parseForm() error {
+	if r.formErr != nil || (r.Form != nil && (r.MultipartForm != nil || !r.IsMultipart())) {
+		return r.formErr
+	}
+
+	var err error
+	if r.IsMultipart() {
+		if err = r.limitBody(r.uploadOptions.maxBodySize()); err != nil {
+			return err
+		}
+		err = r.ParseMultipartForm(r.uploadOptions.MaxMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		r.formErr = bodyError(err)
+	}
+	return r.formErr
+}
+
+// Returns the error of parsing form, such as malformed form or too large body.
+// The error of the form which is parsed by FormValue and its variants is rendered after the action,
+// since they can not return it.
+func (r *Request) FormError() error {
+	return r.formErr
+}
+
+// Returns a boolean indicating whether r's content is a multipart form.
+func (r *Request) IsMultipart() bool {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && contentType == "multipart/form-data"
+}
+
+// Returns the first value of the form's field, including both the URL query and the POST form.
+// The form is parsed on first call, see also FormError.
+func (r *Request) FormValue(name string) string {
+	r.parseForm()
+	return r.Request.FormValue(name)
+}
+
+// Returns the first value of the POST form's field, the form is parsed on first call, see also FormError.
+func (r *Request) PostFormValue(name string) string {
+	r.parseForm()
+	return r.Request.PostFormValue(name)
+}
+
+// Returns the parsed form data, including both the URL query and the POST form.
+// The form is parsed on first call, see also FormError and Context.Bind.
+func (r *Request) FormValues() url.Values {
+	r.parseForm()
+	return r.Form
+}
+
+// Returns the parsed POST form data, the form is parsed on first call, see also FormError.
+func (r *Request) PostFormValues() url.Values {
+	r.parseForm()
+	return r.PostForm
+}
+
 // Returns a boolean indicating whether r is a GET request.
 func (r *Request) IsGet() bool {
 	return strings.EqualFold("GET", r.Method)
@@ -62,3 +147,62 @@ func (r *Request) IsAjax() bool {
 	header := r.Header.Get("X-Requested-With")
 	return strings.EqualFold("XMLHttpRequest", header)
 }
+
+// Returns the best match of the offered media types according to the Accept header.
+// The first offer is returned if there is no Accept header, and empty string is returned if none of them is acceptable.
+// For example, r.Accepts("text/html", "application/json").
+func (r *Request) Accepts(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	header := r.Header.Get("Accept")
+	if len(header) == 0 {
+		return offers[0]
+	}
+
+	type acceptRange struct {
+		mediaType string
+		q         float64
+	}
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType, q})
+	}
+
+	best := ""
+	bestQ := 0.0
+	for _, offer := range offers {
+		// The more specific range takes precedence.
+		q, specificity := 0.0, -1
+		for _, ar := range ranges {
+			s := -1
+			switch {
+			case ar.mediaType == offer:
+				s = 2
+			case strings.HasSuffix(ar.mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(ar.mediaType, "*")):
+				s = 1
+			case ar.mediaType == "*/*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,134 @@
+package clevergo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestAccepts(t *testing.T) {
+	offers := []string{"text/html", "application/json", "application/xml"}
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", "text/html"},
+		{"application/json", "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html"},
+		{"application/json;q=0.5, application/xml", "application/xml"},
+		{"application/*", "application/json"},
+		{"*/*;q=0.1, text/html;q=0", "application/json"},
+		{"image/png", ""},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "/", nil)
+		if len(test.accept) > 0 {
+			r.Header.Set("Accept", test.accept)
+		}
+		if actual := NewRequest(r).Accepts(offers...); actual != test.expected {
+			t.Errorf("Accepts with %q = %q, expected %q", test.accept, actual, test.expected)
+		}
+	}
+}
+
+func TestRequestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8", "::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remoteAddr string
+		header     map[string]string
+		ip         string
+		scheme     string
+		host       string
+	}{
+		{"192.0.2.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Forwarded-Proto": "https"}, "192.0.2.1", "http", "example.com"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 2.2.2.2, 10.0.0.2", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "foo.com"}, "2.2.2.2", "https", "foo.com"},
+		{"10.0.0.1:1234", map[string]string{"X-Real-IP": "3.3.3.3"}, "3.3.3.3", "http", "example.com"},
+		{"[::1]:1234", map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https;host=bar.com, for=10.0.0.3`}, "2001:db8::1", "https", "bar.com"},
+		{"10.0.0.1:1234", map[string]string{"Forwarded": "for=unknown, for=10.0.0.3"}, "10.0.0.3", "http", "example.com"},
+		// The leftmost values are supplied by the client, they should be ignored.
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Forwarded-Proto": "http, https", "X-Forwarded-Host": "evil.com, foo.com"}, "1.1.1.1", "https", "foo.com"},
+		{"10.0.0.1:1234", map[string]string{"Forwarded": "for=1.1.1.1;host=evil.com, for=2.2.2.2;host=foo.com"}, "2.2.2.2", "http", "foo.com"},
+		{"10.0.0.1:1234", map[string]string{"Forwarded": "for=1.1.1.1;host=foo.com, for=10.0.0.2;host=bar.com"}, "1.1.1.1", "http", "foo.com"},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.remoteAddr
+		for key, value := range test.header {
+			r.Header.Set(key, value)
+		}
+		req := NewRequest(r)
+		req.trustedProxies = proxies
+
+		if ip := req.ClientIP(); ip != test.ip {
+			t.Errorf("%d: ClientIP() = %s, expected %s", i, ip, test.ip)
+		}
+		if scheme := req.Scheme(); scheme != test.scheme {
+			t.Errorf("%d: Scheme() = %s, expected %s", i, scheme, test.scheme)
+		}
+		if host := req.ForwardedHost(); host != test.host {
+			t.Errorf("%d: ForwardedHost() = %s, expected %s", i, host, test.host)
+		}
+	}
+}
+
+func TestRequestInput(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?page=2&size=abc&debug=false&flag=maybe&timeout=1m&delay=bogus&since=2016-01-02T15:04:05Z&tag=a&tag=b", nil)
+	r.Header.Set("X-Retry", "3")
+	r.Header.Set("If-Modified-Since", "Sat, 02 Jan 2016 15:04:05 GMT")
+	r.Header.Set("Accept-Language", "en, fr")
+	r.AddCookie(&http.Cookie{Name: "dark", Value: "on"})
+	req := NewRequest(r)
+
+	query := req.QueryInput()
+	if page := query.Int("page", 1); page != 2 {
+		t.Errorf("Int(page) = %d, expected 2", page)
+	}
+	if size := query.Int("size", 10); size != 10 {
+		t.Errorf("Int(size) = %d, expected default 10", size)
+	}
+	if limit := query.Int64("limit", 20); limit != 20 {
+		t.Errorf("Int64(limit) = %d, expected default 20", limit)
+	}
+	if debug := query.Bool("debug", true); debug {
+		t.Error("Bool(debug) = true, expected false")
+	}
+	query.Bool("flag")
+	if timeout := query.Duration("timeout"); timeout != time.Minute {
+		t.Errorf("Duration(timeout) = %s, expected 1m", timeout)
+	}
+	if delay := query.Duration("delay", time.Second); delay != time.Second {
+		t.Errorf("Duration(delay) = %s, expected default 1s", delay)
+	}
+	if since := query.Time("since", time.RFC3339); since.Year() != 2016 {
+		t.Errorf("Time(since) = %s", since)
+	}
+	if tags := query.Strings("tag"); len(tags) != 2 {
+		t.Errorf("Strings(tag) = %v", tags)
+	}
+	errs, ok := query.Err().(InputErrors)
+	if !ok || len(errs) != 3 || errs[0].Field != "size" || errs[1].Field != "flag" || errs[2].Field != "delay" {
+		t.Errorf("Err() = %v, expected errors of size, flag and delay", query.Err())
+	}
+	if status := ToHTTPError(query.Err()).Status; status != http.StatusBadRequest {
+		t.Errorf("status of Err() = %d, expected %d", status, http.StatusBadRequest)
+	}
+
+	header := req.HeaderInput()
+	if retry := header.Int("X-Retry"); retry != 3 {
+		t.Errorf("header Int(X-Retry) = %d, expected 3", retry)
+	}
+	// The HTTP-date contains comma, it should not be split.
+	if since := header.Time("If-Modified-Since", http.TimeFormat); since.Year() != 2016 || header.Err() != nil {
+		t.Errorf("header Time(If-Modified-Since) = %s, %v", since, header.Err())
+	}
+	if languages := header.Strings("Accept-Language"); len(languages) != 2 || languages[1] != "fr" {
+		t.Errorf("header Strings(Accept-Language) = %v, expected [en fr]", languages)
+	}
+	if dark := req.CookieInput().Bool("dark"); !dark {
+		t.Error("cookie Bool(dark) = false, expected true")
+	}
+}
@@ -1,27 +1,56 @@
 package clevergo
 
-import "net/http"
-
+import (
+	"io"
+	"net/http"
+)
+
+// Response is buffered by default, the status, headers and body are sent by Context.Flush after the action.
+//
+// It switches to streaming mode on the first call of Write, Flush or Stream: the status and headers are sent
+// immediately, the data is written to the client directly, and the buffered body, the body's reader
+// and the later changes of status and headers are ignored, the errors can not be rendered either.
 type Response struct {
-	writer http.ResponseWriter
-	status int
-	body   string
-	cancel bool
+	writer    http.ResponseWriter
+	status    int
+	body      string
+	reader    io.Reader // the body's reader, it takes precedence over the body.
+	content   *content  // the seekable body, it takes precedence over the reader, see also ServeContent.
+	sendfile  SendfileOptions
+	cancel    bool
+	streaming bool // whether the status and headers have been sent.
 }
 
 func NewResponse(rw http.ResponseWriter) *Response {
 	return &Response{
-		writer: rw,
-		status: http.StatusOK,
-		body:   "",
-		cancel: false,
+		writer:    rw,
+		status:    http.StatusOK,
+		body:      "",
+		reader:    nil,
+		content:   nil,
+		sendfile:  NewSendfileOptions(),
+		cancel:    false,
+		streaming: false,
 	}
 }
 
+func (r *Response) reset(rw http.ResponseWriter) {
+	r.writer = rw
+	r.status = http.StatusOK
+	r.discardBody()
+	r.cancel = false
+	r.streaming = false
+}
+
 func (r *Response) Writer() http.ResponseWriter {
 	return r.writer
 }
 
+// Replace the writer, such as wrapping it to compress the body, it should be called before the response is sent.
+func (r *Response) SetWriter(w http.ResponseWriter) {
+	r.writer = w
+}
+
 func (r *Response) SetCancel(cancel bool) {
 	r.cancel = cancel
 }
@@ -39,41 +68,122 @@ func (r *Response) Header() http.Header {
 }
 
 func (r *Response) SetHtmlHeader() {
-	r.writer.Header().Add("Content-Type", "text/html; charset=utf-8")
+	r.writer.Header().Set("Content-Type", "text/html; charset=utf-8")
 }
 
 func (r *Response) SetJsonHeader() {
-	r.writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	r.writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 }
 
 func (r *Response) SetJsonpHeader() {
-	r.writer.Header().Add("Content-Type", "application/javascript; charset=utf-8")
+	r.writer.Header().Set("Content-Type", "application/javascript; charset=utf-8")
 }
 
 func (r *Response) SetXmlHeader() {
-	r.writer.Header().Add("Content-Type", "application/xml; charset=utf-8")
+	r.writer.Header().Set("Content-Type", "application/xml; charset=utf-8")
 }
 
 func (r *Response) Body() string {
 	return r.body
 }
 
+// Set the body, the body's reader will be discarded.
 func (r *Response) SetBody(body string) {
+	r.discardBody()
 	r.body = body
 }
 
+// Set the body's reader, it will be copied to the client by Context.Flush without buffering,
+// and it will be closed after copying if it is an io.Closer. The buffered body will be discarded.
+func (r *Response) SetBodyReader(reader io.Reader) {
+	r.discardBody()
+	r.reader = reader
+}
+
+// Discard the buffered body and the body's reader.
+func (r *Response) discardBody() {
+	r.body = ""
+	if r.reader != nil {
+		if closer, ok := r.reader.(io.Closer); ok {
+			closer.Close()
+		}
+		r.reader = nil
+	}
+	if r.content != nil {
+		if closer, ok := r.content.reader.(io.Closer); ok {
+			closer.Close()
+		}
+		r.content = nil
+	}
+}
+
+// Returns a boolean indicating whether the response is in streaming mode, the status and headers have been sent.
+func (r *Response) IsStreaming() bool {
+	return r.streaming
+}
+
+// Switch to streaming mode and send the status and headers.
+func (r *Response) startStreaming() {
+	if r.streaming {
+		return
+	}
+	r.discardBody()
+	r.streaming = true
+	r.writer.WriteHeader(r.status)
+}
+
+// Write the data to the client directly, the response switches to streaming mode on first call.
+func (r *Response) Write(p []byte) (int, error) {
+	r.startStreaming()
+	return r.writer.Write(p)
+}
+
+// Flush the written data to the client if the underlying writer supports http.Flusher,
+// the response switches to streaming mode on first call.
+func (r *Response) Flush() {
+	r.startStreaming()
+	if flusher, ok := r.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Stream the response by fn, the status and headers are sent before calling fn, and the data is flushed
+// after fn returns, fn can flush the data by asserting w as http.Flusher. For example:
+//
+//	err := ctx.Response.Stream(func(w io.Writer) error {
+//		for _, row := range rows {
+//			if _, err := fmt.Fprintln(w, row); err != nil {
+//				return err
+//			}
+//			w.(http.Flusher).Flush()
+//		}
+//		return nil
+//	})
+//
+// The error of fn is returned, it can not be rendered since the status and headers have been sent.
+func (r *Response) Stream(fn func(w io.Writer) error) error {
+	r.startStreaming()
+	err := fn(r)
+	r.Flush()
+	return err
+}
+
+// Set status as unauthorized, the body is set as the message if it is given, otherwise the status text.
 func (r *Response) Unauthorized(args ...string) {
 	r.SetStatus(http.StatusUnauthorized)
 	if len(args) > 0 {
 		r.SetBody(args[0])
+	} else {
+		r.SetBody(http.StatusText(http.StatusUnauthorized))
 	}
-	r.SetBody(http.StatusText(http.StatusUnauthorized))
 }
 
+// Set status as method not allowed, the body is set as the message if it is given, otherwise the status text.
 func (r *Response) MethodNotAllowed(args ...string) {
 	r.SetStatus(http.StatusMethodNotAllowed)
 	if len(args) > 0 {
 		r.SetBody(args[0])
+	} else {
+		r.SetBody(http.StatusText(http.StatusMethodNotAllowed))
 	}
-	r.SetBody(http.StatusText(http.StatusMethodNotAllowed))
 }
@@ -0,0 +1,204 @@
+package clevergo
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SendfileOptions specifies how the files are offloaded to the front server, such as nginx's X-Accel-Redirect
+// and Apache's or lighttpd's X-Sendfile, the file's content will not be sent by the application.
+type SendfileOptions struct {
+	Header string // "X-Accel-Redirect" or "X-Sendfile", empty means disabled.
+	Root   string // only the files under the root are offloaded, empty means all.
+	Prefix string // the internal location which the root is mapped to, such as "/protected/", for X-Accel-Redirect.
+}
+
+// Returns the disabled sendfile options.
+func NewSendfileOptions() SendfileOptions {
+	return SendfileOptions{
+		Header: "",
+		Root:   "",
+		Prefix: "",
+	}
+}
+
+// Returns the header's value of the file, false will be returned if the file can not be offloaded.
+func (o SendfileOptions) value(name string) (string, bool) {
+	if o.Header == "" {
+		return "", false
+	}
+
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return "", false
+	}
+	rel := name
+	if o.Root != "" {
+		root, err := filepath.Abs(o.Root)
+		if err != nil {
+			return "", false
+		}
+		if rel, err = filepath.Rel(root, name); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+	}
+
+	if strings.EqualFold(o.Header, "X-Accel-Redirect") {
+		// The value is a URI which is decoded by nginx, so that each segment of the file's path is escaped,
+		// such as "?", "#", "%" and the control characters.
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		for i := 0; i < len(segments); i++ {
+			segments[i] = url.PathEscape(segments[i])
+		}
+		return path.Join("/", o.Prefix, strings.Join(segments, "/")), true
+	}
+	return name, true
+}
+
+// content is the seekable body of response, it is served by http.ServeContent,
+// which handles the Range, If-Modified-Since and other conditional requests.
+type content struct {
+	name    string // the name which is used to detect the content type.
+	modtime time.Time
+	reader  io.ReadSeeker
+}
+
+// Serve the content by http.ServeContent on flushing, the Range, If-Match, If-Unmodified-Since, If-None-Match,
+// If-Modified-Since and If-Range requests are handled, and the Content-Type is detected by the name's extension
+// or the content if it is not set. The buffered body will be discarded, and the reader will be closed after
+// serving if it is an io.Closer.
+func (r *Response) ServeContent(name string, modtime time.Time, reader io.ReadSeeker) {
+	r.discardBody()
+	r.content = &content{
+		name:    name,
+		modtime: modtime,
+		reader:  reader,
+	}
+}
+
+// Serve the file, see also ServeContent. The file will be offloaded to the front server if the sendfile options
+// are set, see also Application.SetSendfileOptions.
+// The HTTPError with status not found or forbidden will be returned if the file can not be served.
+func (r *Response) File(name string) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return fileError(err)
+	}
+	if info.IsDir() {
+		return NewHTTPError(http.StatusNotFound, "")
+	}
+
+	if value, ok := r.sendfile.value(name); ok {
+		r.discardBody()
+		r.Header().Set(r.sendfile.Header, value)
+		return nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return fileError(err)
+	}
+	r.ServeContent(info.Name(), info.ModTime(), file)
+	return nil
+}
+
+// Serve the file as an attachment named filename, the file's base name is used if filename is empty.
+// See also File.
+func (r *Response) FileAttachment(name, filename string) error {
+	if filename == "" {
+		filename = filepath.Base(name)
+	}
+	if err := r.File(name); err != nil {
+		return err
+	}
+	r.Header().Set("Content-Disposition", ContentDisposition("attachment", filename))
+	return nil
+}
+
+// Respond the reader's content as an attachment named filename.
+// The byte ranges and conditional requests are supported if the reader is an io.ReadSeeker, see also ServeContent,
+// otherwise the content is copied as body's reader, and the Content-Type is detected by the filename's extension.
+func (r *Response) Attachment(filename string, reader io.Reader) {
+	r.Header().Set("Content-Disposition", ContentDisposition("attachment", filename))
+
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		r.ServeContent(filename, time.Time{}, seeker)
+		return
+	}
+
+	if r.Header().Get("Content-Type") == "" {
+		contentType := mime.TypeByExtension(filepath.Ext(filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		r.Header().Set("Content-Type", contentType)
+	}
+	r.SetBodyReader(reader)
+}
+
+// Returns the value of Content-Disposition header with the filename (RFC 6266), such as:
+//
+//	attachment; filename="na_ve.txt"; filename*=UTF-8''na%C3%AFve.txt
+//
+// The filename parameter is the ASCII fallback for the old clients, and the filename* parameter
+// is only appended if the filename contains the characters out of ASCII.
+func ContentDisposition(dispositionType, filename string) string {
+	filename = filepath.Base(filename)
+	fallback := make([]byte, 0, len(filename))
+	ascii := true
+	for _, c := range filename {
+		switch {
+		case c > 0x7e || c < 0x20:
+			ascii = false
+			fallback = append(fallback, '_')
+		case c == '"' || c == '\\':
+			fallback = append(fallback, '_')
+		default:
+			fallback = append(fallback, byte(c))
+		}
+	}
+
+	value := dispositionType + `; filename="` + string(fallback) + `"`
+	if !ascii {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// Percent-encode the value, only the attr-char of RFC 5987 are not encoded.
+func encodeRFC5987(value string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&15])
+	}
+	return b.String()
+}
+
+// Convert the error of opening file to HTTPError, the file's path is not exposed in the message.
+func fileError(err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case os.IsNotExist(err):
+		status = http.StatusNotFound
+	case os.IsPermission(err):
+		status = http.StatusForbidden
+	}
+	e := NewHTTPError(status, "")
+	e.Cause = err
+	return e
+}
@@ -0,0 +1,98 @@
+package clevergo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := map[string]string{
+		"report.pdf":     `attachment; filename="report.pdf"`,
+		`a"b.txt`:        `attachment; filename="a_b.txt"`,
+		"naïve file.txt": `attachment; filename="na_ve file.txt"; filename*=UTF-8''na%C3%AFve%20file.txt`,
+	}
+	for filename, expected := range tests {
+		if value := ContentDisposition("attachment", filename); value != expected {
+			t.Errorf("ContentDisposition(%q) = %s, expected %s", filename, value, expected)
+		}
+	}
+}
+
+func TestResponseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clevergo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "report.txt")
+	if err = ioutil.WriteFile(name, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(name)
+
+	tests := []struct {
+		header   map[string]string
+		sendfile SendfileOptions
+		status   int
+		body     string
+	}{
+		{nil, SendfileOptions{}, http.StatusOK, "0123456789"},
+		{map[string]string{"Range": "bytes=2-4"}, SendfileOptions{}, http.StatusPartialContent, "234"},
+		{map[string]string{"If-Modified-Since": info.ModTime().UTC().Format(http.TimeFormat)}, SendfileOptions{}, http.StatusNotModified, ""},
+		{nil, SendfileOptions{Header: "X-Accel-Redirect", Root: dir, Prefix: "/protected/"}, http.StatusOK, ""},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		for key, value := range test.header {
+			r.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		ctx := acquireContext(nil, w, r, nil)
+		ctx.Response.sendfile = test.sendfile
+		if err := ctx.Response.FileAttachment(name, "报告.txt"); err != nil {
+			t.Fatalf("%d: FileAttachment() returns %v", i, err)
+		}
+		ctx.Flush()
+		releaseContext(ctx)
+
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("%d: response = %d %q, expected %d %q", i, w.Code, w.Body.String(), test.status, test.body)
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;") {
+			t.Errorf("%d: Content-Disposition = %q", i, w.Header().Get("Content-Disposition"))
+		}
+		if test.sendfile.Header != "" && w.Header().Get("X-Accel-Redirect") != "/protected/report.txt" {
+			t.Errorf("%d: X-Accel-Redirect = %q", i, w.Header().Get("X-Accel-Redirect"))
+		}
+	}
+
+	ctx := acquireContext(nil, httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	defer releaseContext(ctx)
+	if err := ctx.Response.File(filepath.Join(dir, "missing.txt")); err == nil || ToHTTPError(err).Status != http.StatusNotFound {
+		t.Errorf("File() of missing file returns %v, expected not found", err)
+	}
+}
+
+func TestSendfileValue(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "files")
+	options := SendfileOptions{Header: "X-Accel-Redirect", Root: root, Prefix: "/protected/"}
+	tests := map[string]string{
+		"report.txt":           "/protected/report.txt",
+		"a b?.txt":             "/protected/a%20b%3F.txt",
+		"dir/100%#1.txt":       "/protected/dir/100%25%231.txt",
+		"evil\r\nX-Foo: 1.txt": "/protected/evil%0D%0AX-Foo:%201.txt",
+	}
+	for name, expected := range tests {
+		if value, ok := options.value(filepath.Join(root, filepath.FromSlash(name))); !ok || value != expected {
+			t.Errorf("value(%q) = %q, %v, expected %q", name, value, ok, expected)
+		}
+	}
+	if _, ok := options.value(filepath.Join(root, "..", "secret.txt")); ok {
+		t.Error("the file outside the root should not be offloaded")
+	}
+}
@@ -0,0 +1,179 @@
+package clevergo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errSSEClosed = errors.New("the SSE writer is closed")
+
+// SSE is the writer of Server-Sent Events, it is created by Context.SSE.
+// The methods are safe for concurrent use, and they return the context's error after the client
+// disconnected, so that the action can stop pushing events, for example:
+//
+//	func (c *Notifications) ActionIndex() error {
+//		sse := c.Context.SSE()
+//		sse.Heartbeat(15 * time.Second)
+//		for {
+//			select {
+//			case n := <-notifications:
+//				if err := sse.Send("notification", n.ID, n); err != nil {
+//					return err
+//				}
+//			case <-sse.Done():
+//				return nil
+//			}
+//		}
+//	}
+//
+// The action should not be wrapped by TimeoutMiddleware, which buffers the response.
+type SSE struct {
+	ctx         *Context
+	lastEventID string
+	done        <-chan struct{} // the request context's done channel.
+	mu          sync.Mutex
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// Switch the response to streaming mode and returns the writer of Server-Sent Events,
+// the status and headers are sent immediately.
+func (ctx *Context) SSE() *SSE {
+	header := ctx.Response.Header()
+	header.Set("Content-Type", "text/event-stream; charset=utf-8")
+	header.Set("Cache-Control", "no-cache")
+	// Disable the buffering of nginx.
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Response.Flush()
+
+	s := &SSE{
+		ctx:         ctx,
+		lastEventID: ctx.Request.Header.Get("Last-Event-ID"),
+		done:        ctx.Ctx().Done(),
+		closed:      make(chan struct{}),
+	}
+	// Close the writer on releasing context, so that the heartbeats never touch the released context.
+	ctx.cancels = append(ctx.cancels, s.Close)
+	return s
+}
+
+// Returns the Last-Event-ID header which is sent by the reconnecting client, the action should resume
+// the events after it.
+func (s *SSE) LastEventID() string {
+	return s.lastEventID
+}
+
+// Returns a channel which is closed when the client disconnected or the request's context was done.
+func (s *SSE) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send an event, the event's type and id are omitted if they are empty.
+// The string and []byte data are sent as is, the multiple lines are sent as multiple data fields,
+// other values are encoded as JSON.
+func (s *SSE) Send(event, id string, data interface{}) error {
+	var payload []byte
+	switch value := data.(type) {
+	case string:
+		payload = []byte(value)
+	case []byte:
+		payload = value
+	default:
+		var err error
+		if payload, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if id != "" {
+		buf.WriteString("id: " + sseField(id) + "\n")
+	}
+	if event != "" {
+		buf.WriteString("event: " + sseField(event) + "\n")
+	}
+	// The lines are separated by CRLF, LF or CR, a lone CR must not be sent, since the client treats it
+	// as a line break, which would inject the fields.
+	lines := strings.Split(sseNewlines.Replace(string(payload)), "\n")
+	for _, line := range lines {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+
+	return s.write(buf.Bytes())
+}
+
+// Send the reconnection time hint, the client waits for it before reconnecting.
+func (s *SSE) Retry(d time.Duration) error {
+	return s.write([]byte("retry: " + strconv.FormatInt(int64(d/time.Millisecond), 10) + "\n\n"))
+}
+
+// Send a comment, it is ignored by the client, and it is usually used to keep the connection alive.
+func (s *SSE) Comment(text string) error {
+	return s.write([]byte(": " + sseField(text) + "\n\n"))
+}
+
+// Send comments periodically in a goroutine to keep the connection alive through the proxies,
+// until the writer is closed or the client disconnected.
+func (s *SSE) Heartbeat(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if s.Comment("heartbeat") != nil {
+					return
+				}
+			case <-s.closed:
+				return
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Close the writer and stop the heartbeats, the later writes return an error.
+// It is called automatically after the request was handled.
+func (s *SSE) Close() {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		close(s.closed)
+		s.mu.Unlock()
+	})
+}
+
+// Write and flush the data, the context's error is returned if the client disconnected.
+func (s *SSE) write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.closed:
+		return errSSEClosed
+	default:
+	}
+	if err := s.ctx.Ctx().Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.ctx.Response.Write(data); err != nil {
+		return err
+	}
+	s.ctx.Response.Flush()
+	return nil
+}
+
+// Normalize the line breaks of data to LF.
+var sseNewlines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// Remove the line breaks of the field, which terminate the field.
+func sseField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,158 @@
+package clevergo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrStorageNotFound is returned by Storage.Open if the key does not exist.
+var ErrStorageNotFound = errors.New("The file does not exist.")
+
+// Storage is the backend which stores uploaded files.
+type Storage interface {
+	Save(key string, r io.Reader) error     // stream r into the file named by key.
+	Open(key string) (io.ReadCloser, error) // open the file named by key.
+	Delete(key string) error                // delete the file named by key.
+}
+
+// Returns the cleaned key, error will be returned if the key is empty or escapes from the root.
+func cleanStorageKey(key string) (string, error) {
+	key = filepath.ToSlash(filepath.Clean("/" + key))
+	key = strings.TrimPrefix(key, "/")
+	if len(key) == 0 || key == "." {
+		return "", errors.New("The storage's key is invalid.")
+	}
+	return key, nil
+}
+
+// LocalStorage stores the files in a local directory.
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	key, err := cleanStorageKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Save the file, the directories will be created if they do not exist.
+// The incomplete file will be removed if error reached.
+func (s *LocalStorage) Save(key string, r io.Reader) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(name)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(name)
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrStorageNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(name)
+	if os.IsNotExist(err) {
+		return ErrStorageNotFound
+	}
+	return err
+}
+
+// MemoryStorage stores the files in memory, it is useful for testing.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		files: make(map[string][]byte, 0),
+	}
+}
+
+func (s *MemoryStorage) Save(key string, r io.Reader) error {
+	key, err := cleanStorageKey(key)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = data
+	return nil
+}
+
+func (s *MemoryStorage) Open(key string) (io.ReadCloser, error) {
+	key, err := cleanStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.files[key]
+	if !ok {
+		return nil, ErrStorageNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryStorage) Delete(key string) error {
+	key, err := cleanStorageKey(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[key]; !ok {
+		return ErrStorageNotFound
+	}
+	delete(s.files, key)
+	return nil
+}
@@ -0,0 +1,199 @@
+package clevergo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// UploadOptions specifies the limits of uploaded files.
+type UploadOptions struct {
+	MaxMemory    int64    // the max memory of multipart form, the rest of files is stored in temporary files.
+	MaxSize      int64    // the max size of each file, zero means unlimited.
+	MaxFiles     int      // the max count of files in the request, zero means unlimited.
+	AllowedTypes []string // the allowed MIME types which are sniffed from content, such as "image/*", empty means all.
+}
+
+func NewUploadOptions() UploadOptions {
+	return UploadOptions{
+		MaxMemory:    defaultMaxMemory,
+		MaxSize:      0,
+		MaxFiles:     0,
+		AllowedTypes: nil,
+	}
+}
+
+// UploadedFile is a file of multipart form.
+type UploadedFile struct {
+	Header      *multipart.FileHeader
+	Name        string // the base name of the file which is provided by client.
+	Size        int64  // the size in bytes.
+	ContentType string // the MIME type which is sniffed from content.
+}
+
+// Open the file's content.
+func (f *UploadedFile) Open() (multipart.File, error) {
+	return f.Header.Open()
+}
+
+// Stream the file's content into the storage with the key.
+func (f *UploadedFile) Save(storage Storage, key string) error {
+	file, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return storage.Save(key, file)
+}
+
+// Returns the file's extension which is provided by client, such as ".png".
+func (f *UploadedFile) Ext() string {
+	return strings.ToLower(filepath.Ext(f.Name))
+}
+
+// Returns the max size of the multipart body which is derived from the limits of files,
+// the non-file fields are limited by MaxMemory. Zero means unlimited, it is unlimited unless
+// both MaxSize and MaxFiles are specified.
+func (o UploadOptions) maxBodySize() int64 {
+	if o.MaxSize <= 0 || o.MaxFiles <= 0 {
+		return 0
+	}
+	return o.MaxSize*int64(o.MaxFiles) + o.MaxMemory
+}
+
+// Set the upload options of the request, it should be called before the form is parsed.
+func (r *Request) SetUploadOptions(options UploadOptions) {
+	r.uploadOptions = options
+}
+
+// Returns the upload options of the request.
+func (r *Request) UploadOptions() UploadOptions {
+	return r.uploadOptions
+}
+
+// Returns the first file of the field named name.
+// The HTTPError will be returned if the request is not multipart or the files exceed the limits,
+// http.ErrMissingFile will be returned if there is no such file.
+func (r *Request) File(name string) (*UploadedFile, error) {
+	files, err := r.Files(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return files[0], nil
+}
+
+// Returns the files of the field named name, see also File.
+func (r *Request) Files(name string) ([]*UploadedFile, error) {
+	if err := r.parseMultipartForm(); err != nil {
+		return nil, err
+	}
+
+	headers := r.MultipartForm.File[name]
+	files := make([]*UploadedFile, 0, len(headers))
+	for i := 0; i < len(headers); i++ {
+		file, err := r.checkFile(headers[i])
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Parse the multipart form with the upload options, and check the count of files.
+func (r *Request) parseMultipartForm() error {
+	if !r.IsMultipart() {
+		return NewHTTPError(http.StatusBadRequest, "The request's content is not multipart.")
+	}
+	if err := r.parseForm(); err != nil {
+		return err
+	}
+
+	if r.uploadOptions.MaxFiles > 0 {
+		count := 0
+		for _, headers := range r.MultipartForm.File {
+			count += len(headers)
+		}
+		if count > r.uploadOptions.MaxFiles {
+			return NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Too many files, at most %d files are allowed.", r.uploadOptions.MaxFiles))
+		}
+	}
+
+	return nil
+}
+
+// Check the file's size and MIME type.
+func (r *Request) checkFile(header *multipart.FileHeader) (*UploadedFile, error) {
+	if r.uploadOptions.MaxSize > 0 && header.Size > r.uploadOptions.MaxSize {
+		return nil, NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("The file %s is too large, the max size is %d bytes.", header.Filename, r.uploadOptions.MaxSize))
+	}
+
+	contentType, err := sniffContentType(header)
+	if err != nil {
+		return nil, WrapError(http.StatusBadRequest, err)
+	}
+
+	if len(r.uploadOptions.AllowedTypes) > 0 && !matchMediaType(contentType, r.uploadOptions.AllowedTypes) {
+		return nil, NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("The type of file %s is not allowed: %s.", header.Filename, contentType))
+	}
+
+	return &UploadedFile{
+		Header:      header,
+		Name:        filepath.Base(filepath.Clean("/" + strings.Replace(header.Filename, "\\", "/", -1))),
+		Size:        header.Size,
+		ContentType: contentType,
+	}, nil
+}
+
+// Detect the file's content type by the first 512 bytes.
+func sniffContentType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(buf[:n])
+	if pos := strings.Index(contentType, ";"); pos != -1 {
+		contentType = contentType[:pos]
+	}
+	return contentType, nil
+}
+
+// Returns a boolean indicating whether the media type matches one of the patterns, such as "image/*".
+func matchMediaType(mediaType string, patterns []string) bool {
+	for i := 0; i < len(patterns); i++ {
+		pattern := patterns[i]
+		if pattern == "*/*" || strings.EqualFold(pattern, mediaType) {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert the error of reading body to HTTPError, the error of body size limit is treated as
+// request entity too large, and others are treated as bad request.
+func bodyError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return WrapError(http.StatusRequestEntityTooLarge, err)
+	}
+	return WrapError(http.StatusBadRequest, err)
+}
@@ -0,0 +1,181 @@
+package clevergo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newUploadRequest(files map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range files {
+		part, _ := writer.CreateFormFile("files", name)
+		part.Write([]byte(content))
+	}
+	writer.Close()
+
+	r := httptest.NewRequest("POST", "/upload", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func TestRequestFiles(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + "image data"
+	tests := []struct {
+		files   map[string]string
+		options UploadOptions
+		status  int
+	}{
+		{map[string]string{"a.png": png, "b.txt": "text"}, UploadOptions{MaxMemory: 1024}, 0},
+		{map[string]string{"a.png": png, "b.txt": "text"}, UploadOptions{MaxMemory: 1024, MaxFiles: 1}, http.StatusRequestEntityTooLarge},
+		{map[string]string{"a.png": png}, UploadOptions{MaxMemory: 1024, MaxSize: 4}, http.StatusRequestEntityTooLarge},
+		{map[string]string{"b.txt": "text"}, UploadOptions{MaxMemory: 1024, AllowedTypes: []string{"image/*"}}, http.StatusUnsupportedMediaType},
+	}
+	for i, test := range tests {
+		ctx := acquireContext(NewApplication(), httptest.NewRecorder(), newUploadRequest(test.files), nil)
+		ctx.Request.SetUploadOptions(test.options)
+
+		files, err := ctx.Request.Files("files")
+		if test.status != 0 {
+			if err == nil || ToHTTPError(err).Status != test.status {
+				t.Errorf("%d: Files() returns %v, expected status %d", i, err, test.status)
+			}
+			releaseContext(ctx)
+			continue
+		}
+		if err != nil || len(files) != len(test.files) {
+			t.Errorf("%d: Files() = %v, %v", i, files, err)
+			releaseContext(ctx)
+			continue
+		}
+
+		storage := NewMemoryStorage()
+		for _, file := range files {
+			if file.Name == "a.png" && file.ContentType != "image/png" {
+				t.Errorf("%d: content type of %s = %s, expected image/png", i, file.Name, file.ContentType)
+			}
+			if err = file.Save(storage, "uploads/"+file.Name); err != nil {
+				t.Errorf("%d: Save() returns error: %s", i, err)
+			}
+		}
+		releaseContext(ctx)
+
+		r, err := storage.Open("uploads/b.txt")
+		if err != nil {
+			t.Errorf("%d: Open() returns error: %s", i, err)
+			continue
+		}
+		if data, _ := ioutil.ReadAll(r); string(data) != "text" {
+			t.Errorf("%d: content of saved file = %q, expected \"text\"", i, data)
+		}
+	}
+}
+
+type BodyLimitController struct {
+	WebController
+}
+
+func (c *BodyLimitController) Actions() WebActionRoutes {
+	return WebActionRoutes{
+		"Echo": NewWebActionRoute([]string{"/echo"}, []string{"POST"}),
+	}
+}
+
+// Override the application's limit per route.
+func (c *BodyLimitController) BeforeAction() error {
+	if limit, err := strconv.ParseInt(c.Request().URL.Query().Get("limit"), 10, 64); err == nil {
+		return c.Context.SetMaxBodySize(limit)
+	}
+	return nil
+}
+
+// The error of parsing form should be reported although FormValue ignores it.
+func (c *BodyLimitController) ActionEcho() string {
+	return c.Request().FormValue("name")
+}
+
+func TestSetMaxBodySize(t *testing.T) {
+	Configuration.enableLog = false
+
+	tests := []struct {
+		body          string
+		contentLength int64
+		appLimit      int64
+		routeLimit    string
+		status        int
+	}{
+		{"name=foo", -1, 0, "", http.StatusOK},
+		{"name=foo", -1, 4, "", http.StatusRequestEntityTooLarge},
+		{"name=foo", 8, 4, "16", http.StatusOK},
+		{"name=foo", 8, 4, "", http.StatusRequestEntityTooLarge},
+		{"name=foobarbaz", -1, 16, "4", http.StatusRequestEntityTooLarge},
+		{"name=foo", 8, 0, "4", http.StatusRequestEntityTooLarge},
+		{"name=%zz", -1, 0, "", http.StatusBadRequest},
+	}
+	for i, test := range tests {
+		app := NewApplication()
+		app.SetMaxBodySize(test.appLimit)
+		app.RegisterWebController(&BodyLimitController{})
+		app.Run()
+
+		r := httptest.NewRequest("POST", "/echo?limit="+test.routeLimit, bytes.NewBufferString(test.body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ContentLength = test.contentLength
+		rw := httptest.NewRecorder()
+		app.ServeHTTP(rw, r)
+
+		if rw.Code != test.status {
+			t.Errorf("%d: status = %d, expected %d", i, rw.Code, test.status)
+		}
+		if test.status == http.StatusOK && rw.Body.String() != "foo" {
+			t.Errorf("%d: body = %s, expected foo", i, rw.Body.String())
+		}
+		if test.status == http.StatusRequestEntityTooLarge && test.contentLength > 0 && rw.Header().Get("Connection") != "close" {
+			t.Errorf("%d: the connection should be closed", i)
+		}
+	}
+}
+
+func TestMaxBodySizeConfiguration(t *testing.T) {
+	app := NewApplication()
+	defer func(n int64) {
+		Configuration.serverMaxBodySize = n
+	}(Configuration.serverMaxBodySize)
+
+	// The configuration which is loaded after creating the application is respected.
+	Configuration.serverMaxBodySize = 1024
+	if app.MaxBodySize() != 1024 {
+		t.Errorf("MaxBodySize() = %d, expected 1024", app.MaxBodySize())
+	}
+	app.SetMaxBodySize(0)
+	if app.MaxBodySize() != 0 {
+		t.Errorf("MaxBodySize() = %d, expected 0", app.MaxBodySize())
+	}
+}
+
+func TestUploadBodyLimit(t *testing.T) {
+	options := UploadOptions{MaxMemory: 16, MaxSize: 4, MaxFiles: 2}
+	for _, contentLength := range []int64{0, -1} {
+		r := newUploadRequest(map[string]string{"a.txt": "a large file which exceeds the limit"})
+		if contentLength < 0 {
+			// The body whose size is unknown is limited while parsing.
+			r.ContentLength = contentLength
+		}
+		ctx := acquireContext(NewApplication(), httptest.NewRecorder(), r, nil)
+		ctx.Request.SetUploadOptions(options)
+
+		_, err := ctx.Request.Files("files")
+		if err == nil || ToHTTPError(err).Status != http.StatusRequestEntityTooLarge {
+			t.Errorf("Content-Length %d: Files() returns %v, expected status %d", r.ContentLength, err, http.StatusRequestEntityTooLarge)
+		}
+		if ctx.Request.MultipartForm != nil && len(ctx.Request.MultipartForm.File) > 0 {
+			t.Errorf("Content-Length %d: the files should not be parsed", r.ContentLength)
+		}
+		releaseContext(ctx)
+	}
+}
@@ -0,0 +1,402 @@
+package clevergo
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// FieldError is the validation error of a field.
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`                     // field's name, the json tag is preferred.
+	Rule    string `json:"rule" xml:"rule"`                       // the failed rule, such as "required".
+	Param   string `json:"param,omitempty" xml:"param,omitempty"` // the rule's param, such as "3" of "min=3".
+	Message string `json:"message" xml:"message"`                 // human-readable message.
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors is returned by Validate and Context.Bind if the value is invalid.
+type ValidationErrors []*FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i := 0; i < len(errs); i++ {
+		messages[i] = errs[i].Message
+	}
+	return strings.Join(messages, " ")
+}
+
+// Returns the first error's message of each field, it is convenient to re-display the errors in forms.
+func (errs ValidationErrors) Map() map[string]string {
+	m := make(map[string]string, len(errs))
+	for i := 0; i < len(errs); i++ {
+		if _, ok := m[errs[i].Field]; !ok {
+			m[errs[i].Field] = errs[i].Message
+		}
+	}
+	return m
+}
+
+// Convert to HTTPError with status unprocessable entity, the field errors are set as details.
+func (errs ValidationErrors) HTTPError() *HTTPError {
+	return &HTTPError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "validation_failed",
+		Message: "The submitted data is invalid.",
+		Details: errs,
+		Cause:   errs,
+	}
+}
+
+// ruleError is returned if the rule of "validate" tag is unknown, it is a programmer's error,
+// so that it is treated as internal server error instead of bad request.
+type ruleError struct {
+	rule string
+}
+
+func (e *ruleError) Error() string {
+	return fmt.Sprintf("The validation rule is unknown: %s.", e.rule)
+}
+
+func (e *ruleError) HTTPError() *HTTPError {
+	return WrapError(http.StatusInternalServerError, e)
+}
+
+// ValidatorFunc reports whether the value satisfies the rule with the param.
+type ValidatorFunc func(v reflect.Value, param string) bool
+
+type validator struct {
+	fn      ValidatorFunc
+	message string // the message's format, the arguments are field's name and rule's param.
+}
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]*validator{
+		"required": {validateRequired, "%s is required."},
+		"min":      {validateMin, "%s must be at least %s."},
+		"max":      {validateMax, "%s must be at most %s."},
+		"len":      {validateLen, "%s must be exactly %s in length."},
+		"email":    {validateEmail, "%s must be a valid email address."},
+		"url":      {validateURL, "%s must be a valid URL."},
+		"oneof":    {validateOneOf, "%s must be one of %s."},
+		"alpha":    {validateAlpha, "%s must contain only letters."},
+		"alphanum": {validateAlphanum, "%s must contain only letters and numbers."},
+		"numeric":  {validateNumeric, "%s must be numeric."},
+	}
+	emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+)
+
+// Register a validation rule, message is the format of error message,
+// its arguments are the field's name and the rule's param, for example, "%s must be at least %s.".
+func RegisterValidator(name string, fn ValidatorFunc, message string) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = &validator{fn, message}
+}
+
+func getValidator(name string) (*validator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	v, ok := validators[name]
+	return v, ok
+}
+
+// Validate the struct by the "validate" tag of fields, the rules are separated by comma, for example:
+//
+//	type SignUpForm struct {
+//		Name  string `form:"name" validate:"required,min=3"`
+//		Email string `form:"email" validate:"required,email"`
+//	}
+//
+// The empty string, slice, map and nil pointer are only checked by "required", the numbers and booleans
+// are checked by all the rules, for example, 0 is invalid for "min=1".
+// The nested structs are validated recursively, the struct which has been validated is skipped.
+// ValidationErrors will be returned if the struct is invalid, and the error which is converted to
+// internal server error will be returned if the rule is unknown, see also CheckValidationRules.
+func Validate(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	visited := make(map[uintptr]bool)
+	if pv := reflect.ValueOf(v); pv.Kind() == reflect.Ptr {
+		visited[pv.Pointer()] = true
+	}
+	errs := make(ValidationErrors, 0)
+	if err := validateStruct(rv, "", visited, &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(sv reflect.Value, prefix string, visited map[uintptr]bool, errs *ValidationErrors) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		name := prefix + fieldName(field)
+		fv := sv.Field(i)
+
+		if rules := field.Tag.Get("validate"); len(rules) > 0 && rules != "-" {
+			for _, rule := range strings.Split(rules, ",") {
+				ruleName, param := rule, ""
+				if pos := strings.Index(rule, "="); pos != -1 {
+					ruleName, param = rule[:pos], rule[pos+1:]
+				}
+
+				validator, ok := getValidator(ruleName)
+				if !ok {
+					return &ruleError{ruleName}
+				}
+
+				// The optional field is only checked by "required".
+				if ruleName != "required" && isOmittedValue(fv) {
+					continue
+				}
+
+				if !validator.fn(fv, param) {
+					*errs = append(*errs, &FieldError{
+						Field:   name,
+						Rule:    ruleName,
+						Param:   param,
+						Message: fmt.Sprintf(validator.message, name, param),
+					})
+					break
+				}
+			}
+		}
+
+		// Validate nested struct, the pointers those have been visited are skipped to avoid the cycles.
+		if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			if visited[fv.Pointer()] {
+				continue
+			}
+			visited[fv.Pointer()] = true
+		}
+		nested := reflect.Indirect(fv)
+		if nested.Kind() == reflect.Struct && nested.Type().PkgPath() != "time" {
+			if err := validateStruct(nested, name+".", visited, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Check the rules of "validate" tags of the struct's type and its nested structs,
+// the error will be returned if a rule is unknown. It is called on registering actions,
+// so that the invalid rules are reported on running.
+func CheckValidationRules(t reflect.Type) error {
+	return checkRules(t, make(map[reflect.Type]bool))
+}
+
+func checkRules(t reflect.Type, visited map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		if rules := field.Tag.Get("validate"); len(rules) > 0 && rules != "-" {
+			for _, rule := range strings.Split(rules, ",") {
+				if pos := strings.Index(rule, "="); pos != -1 {
+					rule = rule[:pos]
+				}
+				if _, ok := getValidator(rule); !ok {
+					return fmt.Errorf("The validation rule of %s.%s is unknown: %s.", t.Name(), field.Name, rule)
+				}
+			}
+		}
+		if err := checkRules(field.Type, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the field's name in errors, the json tag and form tag are preferred.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query", "param"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	}
+	return false
+}
+
+// Returns a boolean indicating whether the optional value is omitted, that is an empty string, slice or map,
+// or a nil pointer. The numbers and booleans are never omitted.
+func isOmittedValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+func validateRequired(v reflect.Value, param string) bool {
+	return !isEmptyValue(v)
+}
+
+// Compare the number, or the length of string, slice and map with the param.
+// Returns -1, 0, 1 if it is less than, equal to or greater than the param, ok is false if it cannot be compared.
+func compareSize(v reflect.Value, param string) (result int, ok bool) {
+	v = reflect.Indirect(v)
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	var size float64
+	switch v.Kind() {
+	case reflect.String:
+		size = float64(len([]rune(v.String())))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		size = float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	default:
+		return 0, false
+	}
+
+	switch {
+	case size < n:
+		return -1, true
+	case size > n:
+		return 1, true
+	}
+	return 0, true
+}
+
+func validateMin(v reflect.Value, param string) bool {
+	result, ok := compareSize(v, param)
+	return ok && result >= 0
+}
+
+func validateMax(v reflect.Value, param string) bool {
+	result, ok := compareSize(v, param)
+	return ok && result <= 0
+}
+
+func validateLen(v reflect.Value, param string) bool {
+	result, ok := compareSize(v, param)
+	return ok && result == 0
+}
+
+func validateEmail(v reflect.Value, param string) bool {
+	v = reflect.Indirect(v)
+	return v.Kind() == reflect.String && emailRegexp.MatchString(v.String())
+}
+
+func validateURL(v reflect.Value, param string) bool {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.String {
+		return false
+	}
+	u, err := url.Parse(v.String())
+	return err == nil && len(u.Scheme) > 0 && len(u.Host) > 0
+}
+
+// The options of param are separated by space, for example, "oneof=red green blue".
+func validateOneOf(v reflect.Value, param string) bool {
+	value := fmt.Sprint(reflect.Indirect(v).Interface())
+	for _, option := range strings.Fields(param) {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRunes(v reflect.Value, fn func(rune) bool) bool {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.String {
+		return false
+	}
+	for _, r := range v.String() {
+		if !fn(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateAlpha(v reflect.Value, param string) bool {
+	return validateRunes(v, unicode.IsLetter)
+}
+
+func validateAlphanum(v reflect.Value, param string) bool {
+	return validateRunes(v, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	})
+}
+
+func validateNumeric(v reflect.Value, param string) bool {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.String {
+		return isBasicKind(v.Kind()) && v.Kind() != reflect.String && v.Kind() != reflect.Bool
+	}
+	_, err := strconv.ParseFloat(v.String(), 64)
+	return err == nil
+}
+
+// Returns the first error's message of each field if err is ValidationErrors, otherwise nil.
+// It is convenient to re-display the errors in forms, for example:
+//
+//	if err := ctx.Bind(&form); err != nil {
+//		wc.Render(map[string]interface{}{"form": form, "errors": clevergo.FieldErrors(err)})
+//	}
+func FieldErrors(err error) map[string]string {
+	if errs, ok := err.(ValidationErrors); ok {
+		return errs.Map()
+	}
+	return nil
+}
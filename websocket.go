@@ -0,0 +1,539 @@
+package clevergo
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// The message types of WebSocket (RFC 6455).
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// The status codes of close frame.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// The max size of message if WebSocketOptions.MaxMessageSize is zero, since the messages are buffered in memory.
+const maxWebSocketMessageSize = 64 << 20 // 64 MB
+
+var errWebSocketClosed = errors.New("websocket: the connection is closed")
+
+// WebSocketHandler handles the upgraded connection, the connection is closed after it returns.
+type WebSocketHandler func(ws *WebSocket)
+
+// WebSocketOptions specifies the handshake and the limits of WebSocket connections.
+type WebSocketOptions struct {
+	Subprotocols    []string              // the supported subprotocols in order of preference.
+	CheckOrigin     func(r *Request) bool // nil means that only the same origin is allowed, see also Request.ForwardedHost.
+	MaxMessageSize  int64                 // the max size of message in bytes, zero means 64 MB.
+	PingInterval    time.Duration         // the interval of pings, zero means disabled.
+	ReadTimeout     time.Duration         // the connection is closed if nothing is received in time, zero means unlimited.
+	WriteTimeout    time.Duration         // the timeout of writing a frame, zero means unlimited.
+	Middlewares     []Middleware          // the middlewares which are appended to the application's.
+	SkipMiddlewares SkipMiddlewares       // the application's middlewares those are skipped, TimeoutMiddleware is skipped by default.
+}
+
+func NewWebSocketOptions() WebSocketOptions {
+	return WebSocketOptions{
+		Subprotocols:    nil,
+		CheckOrigin:     nil,
+		MaxMessageSize:  1 << 20, // 1 MB
+		PingInterval:    30 * time.Second,
+		ReadTimeout:     60 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		Middlewares:     nil,
+		SkipMiddlewares: nil,
+	}
+}
+
+type webSocketRoute struct {
+	path    string
+	handler WebSocketHandler
+	options WebSocketOptions
+}
+
+// Add a WebSocket route, the request runs through the application's middlewares before upgrading,
+// so that the handler can access the session and the JWT token by ws.Context().
+// The session is read-only, the changes will not be saved, since no header can be sent after upgrading.
+func (a *Application) AddWebSocket(path string, handler WebSocketHandler, options WebSocketOptions) {
+	a.webSockets = append(a.webSockets, &webSocketRoute{
+		path:    path,
+		handler: handler,
+		options: options,
+	})
+}
+
+// The ID of the middleware package's TimeoutMiddleware, it is skipped by the WebSocket routes unless it is
+// set in the options's SkipMiddlewares, since the connection can not be hijacked from the timed out handler,
+// and it outlives the request's timeout.
+const timeoutMiddlewareID = "TimeoutMiddleware"
+
+// Generate the WebSocket route's handler.
+func (a *Application) webSocketHandle(route *webSocketRoute) httprouter.Handle {
+	middlewares := make([]Middleware, 0, len(a.middlewares)+len(route.options.Middlewares))
+	for i := 0; i < len(a.middlewares); i++ {
+		id := a.middlewares[i].ID()
+		if skip, ok := route.options.SkipMiddlewares[id]; (ok && skip) || (!ok && id == timeoutMiddlewareID) {
+			continue
+		}
+		middlewares = append(middlewares, a.middlewares[i])
+	}
+	middlewares = append(middlewares, route.options.Middlewares...)
+	handler := chainMiddlewares(middlewares, HandlerFunc(func(ctx *Context) {
+		if err := serveWebSocket(ctx, route.handler, route.options); err != nil {
+			ctx.Error(err)
+		}
+	}))
+
+	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		ctx := acquireContext(a, rw, r, params)
+		ctx.SkipMiddlewares = route.options.SkipMiddlewares
+
+		defer releaseContext(ctx)
+		defer ctx.Flush()
+
+		if Configuration.enableLog {
+			ctx.Log = a.logger.NewLog()
+			defer ctx.Log.Flush()
+		}
+
+		handler.Handle(ctx)
+	}
+}
+
+// Upgrade the connection and run the handler, the HTTPError will be returned if the handshake failed.
+func serveWebSocket(ctx *Context, handler WebSocketHandler, options WebSocketOptions) error {
+	r := ctx.Request
+	if r.Method != "GET" || !hasToken(r.Header, "Connection", "upgrade") || !hasToken(r.Header, "Upgrade", "websocket") {
+		return NewHTTPError(http.StatusBadRequest, "The request is not a WebSocket handshake.")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		ctx.Response.Header().Set("Sec-WebSocket-Version", "13")
+		return NewHTTPError(http.StatusUpgradeRequired, "The WebSocket version is not supported.")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return NewHTTPError(http.StatusBadRequest, "The Sec-WebSocket-Key is invalid.")
+	}
+	checkOrigin := options.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return NewHTTPError(http.StatusForbidden, "The origin is not allowed.")
+	}
+
+	// Load the session before hijacking, so that the handler can access it.
+	// It is read-only, since the session's cookie can not be sent after hijacking.
+	if ctx.app != nil && ctx.app.sessionStore != nil && ctx.Session == nil {
+		if err := ctx.GetSession(); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := ctx.Response.writer.(*timeoutWriter); ok {
+		return errors.New("websocket: the connection can not be upgraded in Context.HandleTimeout, the WebSocket route should skip TimeoutMiddleware")
+	}
+	hijacker, ok := ctx.Response.writer.(http.Hijacker)
+	if !ok {
+		return errors.New("websocket: the response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return err
+	}
+	// The connection is taken over, the response must not be flushed.
+	ctx.Response.cancel = true
+	conn.SetDeadline(time.Time{})
+
+	subprotocol := selectSubprotocol(r, options.Subprotocols)
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	response += "\r\n"
+	if _, err = rw.WriteString(response); err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil
+	}
+
+	ws := newWebSocket(ctx, conn, rw.Reader, subprotocol, options)
+	defer ws.Close(CloseNormalClosure, "")
+	handler(ws)
+	return nil
+}
+
+// WebSocket is an upgraded connection, the reads must be done by one goroutine,
+// and the writes are safe for concurrent use.
+// It must not be used after the handler returns.
+type WebSocket struct {
+	ctx         *Context
+	conn        net.Conn
+	reader      *bufio.Reader
+	subprotocol string
+	options     WebSocketOptions
+	writeMu     sync.Mutex
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+func newWebSocket(ctx *Context, conn net.Conn, reader *bufio.Reader, subprotocol string, options WebSocketOptions) *WebSocket {
+	ws := &WebSocket{
+		ctx:         ctx,
+		conn:        conn,
+		reader:      reader,
+		subprotocol: subprotocol,
+		options:     options,
+		closed:      make(chan struct{}),
+	}
+	ws.extendReadDeadline()
+	if options.PingInterval > 0 {
+		go ws.keepalive()
+	}
+	return ws
+}
+
+// Returns the context of the upgraded request, such as the session and JWT token.
+// The session is read-only, its changes will not be saved.
+func (ws *WebSocket) Context() *Context {
+	return ws.ctx
+}
+
+// Returns the negotiated subprotocol, empty means none.
+func (ws *WebSocket) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// CloseError is returned by ReadMessage if the peer closed the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// protocolError is the violation of the protocol, the connection is closed with the code.
+type protocolError struct {
+	code    int
+	message string
+}
+
+func (e *protocolError) Error() string {
+	return "websocket: " + e.message
+}
+
+// Read a message, the fragmented message is reassembled, the pings are replied automatically.
+// *CloseError will be returned if the peer closed the connection, and the connection is closed with
+// the corresponding status if the peer violates the protocol or the message exceeds the max size.
+func (ws *WebSocket) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		fin, opcode, payload, err := ws.readFrame(ws.maxMessageSize() - int64(len(data)))
+		if err != nil {
+			return 0, nil, ws.fail(err)
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := ws.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			closeErr := &CloseError{Code: CloseNoStatusReceived}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Text = string(payload[2:])
+			}
+			ws.writeClose(closeErr.Code, "")
+			ws.closeConn()
+			return 0, nil, closeErr
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, ws.fail(&protocolError{CloseProtocolError, "unexpected continuation frame"})
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, ws.fail(&protocolError{CloseProtocolError, "the fragmented message is not finished"})
+			}
+			messageType = opcode
+		default:
+			return 0, nil, ws.fail(&protocolError{CloseProtocolError, "unknown opcode"})
+		}
+
+		data = append(data, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, ws.fail(&protocolError{CloseInvalidPayload, "invalid UTF-8 text"})
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// Read a message and decode it as JSON.
+func (ws *WebSocket) ReadJSON(v interface{}) error {
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Write a text or binary message.
+func (ws *WebSocket) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errors.New("websocket: invalid message type")
+	}
+	return ws.writeFrame(messageType, data)
+}
+
+func (ws *WebSocket) WriteText(text string) error {
+	return ws.writeFrame(TextMessage, []byte(text))
+}
+
+// Encode v as JSON and write it as a text message.
+func (ws *WebSocket) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.writeFrame(TextMessage, data)
+}
+
+// Send a close frame with the code and reason, and close the connection.
+func (ws *WebSocket) Close(code int, reason string) error {
+	err := ws.writeClose(code, reason)
+	ws.closeConn()
+	return err
+}
+
+// Returns the max size of message, the messages are always limited, since they are buffered in memory.
+func (ws *WebSocket) maxMessageSize() int64 {
+	if ws.options.MaxMessageSize > 0 {
+		return ws.options.MaxMessageSize
+	}
+	return maxWebSocketMessageSize
+}
+
+// Read a frame, the data frame's payload must not exceed the limit.
+func (ws *WebSocket) readFrame(limit int64) (fin bool, opcode int, payload []byte, err error) {
+	var header [8]byte
+	if _, err = io.ReadFull(ws.reader, header[:2]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+	if header[0]&0x70 != 0 {
+		err = &protocolError{CloseProtocolError, "reserved bits are set"}
+		return
+	}
+	if header[1]&0x80 == 0 {
+		err = &protocolError{CloseProtocolError, "the client's frame is not masked"}
+		return
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		if _, err = io.ReadFull(ws.reader, header[:2]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(header[:2]))
+	case 127:
+		if _, err = io.ReadFull(ws.reader, header[:8]); err != nil {
+			return
+		}
+		if header[0]&0x80 != 0 {
+			err = &protocolError{CloseProtocolError, "invalid payload length"}
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(header[:8]))
+	}
+
+	if opcode >= CloseMessage {
+		if !fin || length > 125 {
+			err = &protocolError{CloseProtocolError, "invalid control frame"}
+			return
+		}
+	} else if length > limit {
+		err = &protocolError{CloseMessageTooBig, "the message is too big"}
+		return
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(ws.reader, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(ws.reader, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	ws.extendReadDeadline()
+	return
+}
+
+// Write a frame, the server's frames are not masked.
+func (ws *WebSocket) writeFrame(opcode int, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	select {
+	case <-ws.closed:
+		return errWebSocketClosed
+	default:
+	}
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(opcode))
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 126, byte(length>>8), byte(length))
+	default:
+		frame = append(frame, 127)
+		frame = append(frame, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(frame[len(frame)-8:], uint64(length))
+	}
+	frame = append(frame, payload...)
+
+	if ws.options.WriteTimeout > 0 {
+		ws.conn.SetWriteDeadline(time.Now().Add(ws.options.WriteTimeout))
+	}
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+func (ws *WebSocket) writeClose(code int, reason string) error {
+	if code == CloseNoStatusReceived {
+		return ws.writeFrame(CloseMessage, nil)
+	}
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	return ws.writeFrame(CloseMessage, payload)
+}
+
+// Close the connection with the status of the protocol error, and returns the error.
+func (ws *WebSocket) fail(err error) error {
+	if e, ok := err.(*protocolError); ok {
+		ws.writeClose(e.code, e.message)
+	}
+	ws.closeConn()
+	return err
+}
+
+func (ws *WebSocket) closeConn() {
+	ws.closeOnce.Do(func() {
+		ws.writeMu.Lock()
+		close(ws.closed)
+		ws.writeMu.Unlock()
+		ws.conn.Close()
+	})
+}
+
+func (ws *WebSocket) extendReadDeadline() {
+	if ws.options.ReadTimeout > 0 {
+		ws.conn.SetReadDeadline(time.Now().Add(ws.options.ReadTimeout))
+	}
+}
+
+// Send pings periodically until the connection is closed, the pongs extend the read deadline.
+func (ws *WebSocket) keepalive() {
+	ticker := time.NewTicker(ws.options.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if ws.writeFrame(PingMessage, nil) != nil {
+				return
+			}
+		case <-ws.closed:
+			return
+		}
+	}
+}
+
+// Returns a boolean indicating whether the header contains the token, case insensitive.
+func hasToken(header http.Header, name, token string) bool {
+	for _, value := range headerValues(header, name) {
+		if strings.EqualFold(value, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a boolean indicating whether the Origin is absent or its host equals the request's host.
+func sameOrigin(r *Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.ForwardedHost())
+}
+
+// Returns the first subprotocol of the server's which is requested by the client.
+func selectSubprotocol(r *Request, subprotocols []string) string {
+	requested := headerValues(r.Header, "Sec-WebSocket-Protocol")
+	for _, subprotocol := range subprotocols {
+		for _, value := range requested {
+			if value == subprotocol {
+				return subprotocol
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package clevergo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Write a masked frame as the client.
+func writeClientFrame(w io.Writer, opcode int, payload []byte) error {
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | byte(opcode)}
+	if len(payload) <= 125 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = append(frame, 0x80|126, byte(len(payload)>>8), byte(len(payload)))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// Read an unmasked frame as the client.
+func readServerFrame(r io.Reader) (int, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, nil, err
+		}
+		length = int(binary.BigEndian.Uint16(header))
+	}
+	payload := make([]byte, length)
+	_, err := io.ReadFull(r, payload)
+	return int(header[0] & 0x0f), payload, err
+}
+
+func dialWebSocket(t *testing.T, addr, origin string) (net.Conn, *bufio.Reader, int) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	request := "GET /ws HTTP/1.1\r\nHost: " + addr + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Protocol: chat\r\nOrigin: " + origin + "\r\n\r\n"
+	if _, err = conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode == http.StatusSwitchingProtocols {
+		if accept := response.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+			t.Errorf("Sec-WebSocket-Accept = %q", accept)
+		}
+		if protocol := response.Header.Get("Sec-WebSocket-Protocol"); protocol != "chat" {
+			t.Errorf("Sec-WebSocket-Protocol = %q, expected chat", protocol)
+		}
+	}
+	return conn, reader, response.StatusCode
+}
+
+func TestWebSocket(t *testing.T) {
+	app := NewApplication()
+	options := NewWebSocketOptions()
+	options.Subprotocols = []string{"chat"}
+	options.MaxMessageSize = 16
+	app.AddWebSocket("/ws", func(ws *WebSocket) {
+		for {
+			messageType, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			ws.WriteMessage(messageType, data)
+		}
+	}, options)
+	app.Run()
+	server := httptest.NewServer(app)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	if _, _, status := dialWebSocket(t, addr, "http://evil.com"); status != http.StatusForbidden {
+		t.Errorf("status of cross origin handshake = %d, expected %d", status, http.StatusForbidden)
+	}
+
+	conn, reader, status := dialWebSocket(t, addr, "http://"+addr)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("status of handshake = %d, expected %d", status, http.StatusSwitchingProtocols)
+	}
+	defer conn.Close()
+
+	writeClientFrame(conn, PingMessage, []byte("ping"))
+	if opcode, payload, err := readServerFrame(reader); err != nil || opcode != PongMessage || string(payload) != "ping" {
+		t.Errorf("reply of ping = %d %q %v", opcode, payload, err)
+	}
+
+	writeClientFrame(conn, TextMessage, []byte("hello"))
+	if opcode, payload, err := readServerFrame(reader); err != nil || opcode != TextMessage || string(payload) != "hello" {
+		t.Errorf("echo = %d %q %v", opcode, payload, err)
+	}
+
+	writeClientFrame(conn, BinaryMessage, []byte(strings.Repeat("x", 32)))
+	opcode, payload, err := readServerFrame(reader)
+	if err != nil || opcode != CloseMessage || len(payload) < 2 || binary.BigEndian.Uint16(payload) != CloseMessageTooBig {
+		t.Errorf("reply of too big message = %d %q %v, expected close %d", opcode, payload, err, CloseMessageTooBig)
+	}
+}
+
+func TestWebSocketFrameLimit(t *testing.T) {
+	// The frame claims a huge payload, it should be rejected before allocating the payload.
+	frame := []byte{0x80 | byte(BinaryMessage), 0x80 | 127, 0, 0, 1, 0, 0, 0, 0, 0}
+	ws := &WebSocket{
+		reader:  bufio.NewReader(strings.NewReader(string(frame))),
+		options: WebSocketOptions{MaxMessageSize: 0},
+	}
+	_, _, _, err := ws.readFrame(ws.maxMessageSize())
+	if e, ok := err.(*protocolError); !ok || e.code != CloseMessageTooBig {
+		t.Errorf("readFrame() returns %v, expected the message is too big", err)
+	}
+}